@@ -1,34 +1,72 @@
 package build
 
 import (
+	"errors"
 	"fmt"
+	"os"
 
-	"github.com/zhhc99/bgen/internal/config"
 	"github.com/zhhc99/bgen/internal/site"
 )
 
+// Run 检查配置后构建站点.
 func Run(projectRoot, outDir string) error {
-	cfg, err := config.Load(projectRoot)
+	cfg, warnings, err := prepare(projectRoot)
 	if err != nil {
-		return fmt.Errorf("loading config: %w", err)
+		return err
 	}
-	s := site.New(cfg)
-	if err := s.Build(projectRoot, outDir); err != nil {
-		return fmt.Errorf("building site: %w", err)
+	if err := checkStrict(cfg, warnings); err != nil {
+		return err
+	}
+	buildDir := outDir
+	if cfg.CheckLinks {
+		// 旧的输出不能掩盖坏链接
+		tmpDir, err := os.MkdirTemp("", "bgen-build-*")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tmpDir)
+		buildDir = tmpDir
+	}
+	res, err := site.Build(cfg, projectRoot, buildDir)
+	printWarnings(res.Warnings)
+	if err != nil {
+		return siteError("building site", err)
+	}
+	if cfg.CheckLinks {
+		if err := checkLinks(cfg, buildDir, res.Pages); err != nil {
+			return err
+		}
+		if err := copyOutput(buildDir, outDir); err != nil {
+			return fmt.Errorf("writing output: %w", err)
+		}
 	}
 	fmt.Printf("build complete -> %s\n", outDir)
 	return nil
 }
 
+// RunDev 用于 dev server, 忽略 strict.
 func RunDev(projectRoot, outDir string) error {
-	cfg, err := config.Load(projectRoot)
+	cfg, _, err := prepare(projectRoot)
 	if err != nil {
-		return fmt.Errorf("loading config: %w", err)
+		return err
 	}
-	cfg.BasePath = ""
-	s := site.New(cfg)
-	if err := s.Build(projectRoot, outDir); err != nil {
-		return fmt.Errorf("building site: %w", err)
+	cfg.BasePath, cfg.Strict = "", false
+	res, err := site.Build(cfg, projectRoot, outDir)
+	printWarnings(res.Warnings)
+	if err != nil {
+		return siteError("building site", err)
 	}
 	return nil
 }
+
+// siteError 逐条打印 site.Errors, 返回一个汇总.
+func siteError(action string, err error) error {
+	var errs site.Errors
+	if !errors.As(err, &errs) {
+		return fmt.Errorf("%s: %w", action, err)
+	}
+	for _, e := range errs {
+		fmt.Fprintf(os.Stderr, "error: %s\n", e)
+	}
+	return fmt.Errorf("%s: %d error(s)", action, len(errs))
+}
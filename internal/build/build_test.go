@@ -5,6 +5,8 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/zhhc99/bgen/internal/build"
@@ -157,3 +159,1681 @@ func TestBuild_Idempotent(t *testing.T) {
 		t.Fatalf("second build: %v", err)
 	}
 }
+
+func TestBuild_PostNavigation(t *testing.T) {
+	if _, err := exec.LookPath("pandoc"); err != nil {
+		t.Skip("pandoc not found in PATH")
+	}
+
+	dir := makeProject(t)
+	mustWrite(t, filepath.Join(dir, "content/posts/go-more.md"), `---
+title: More Go
+date: 2024-03-01
+tags: [go]
+---
+
+另一篇关于 go 的文章.
+`)
+	mustWrite(t, filepath.Join(dir, "content/posts/secret.md"), `---
+title: Secret Post
+date: 2024-01-15
+unlisted: true
+tags: [go, hidden]
+---
+
+不公开的文章.
+`)
+	outDir := filepath.Join(dir, "output")
+	if err := build.Run(dir, outDir); err != nil {
+		t.Fatalf("build.Run: %v", err)
+	}
+
+	// math 位于 hello 和 go-more 之间, unlisted 的 secret 被跳过
+	mathHTML, err := os.ReadFile(filepath.Join(outDir, "posts/math/index.html"))
+	if err != nil {
+		t.Fatalf("reading post html: %v", err)
+	}
+	if !bytes.Contains(mathHTML, []byte(`href="/posts/hello/" class="prev"`)) {
+		t.Error("math post missing prev link to hello")
+	}
+	if !bytes.Contains(mathHTML, []byte(`href="/posts/go-more/" class="next"`)) {
+		t.Error("math post missing next link to go-more")
+	}
+
+	// hello 和 go-more 共享 tag go, 互为相关文章
+	goHTML, err := os.ReadFile(filepath.Join(outDir, "posts/go-more/index.html"))
+	if err != nil {
+		t.Fatalf("reading post html: %v", err)
+	}
+	if !bytes.Contains(goHTML, []byte(`class="related"`)) || !bytes.Contains(goHTML, []byte("Hello World")) {
+		t.Error("go-more post missing related post hello")
+	}
+
+	secretHTML, err := os.ReadFile(filepath.Join(outDir, "posts/secret/index.html"))
+	if err != nil {
+		t.Fatalf("unlisted post should still be rendered: %v", err)
+	}
+	// hidden 只被 unlisted 文章使用, 没有 tag 页
+	if !bytes.Contains(secretHTML, []byte(`href="/tags/go/"`)) || bytes.Contains(secretHTML, []byte(`href="/tags/hidden/"`)) {
+		t.Error("unlisted post should only link tags that have a tag page")
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "tags/hidden")); err == nil {
+		t.Error("tag used only by unlisted posts should not get a page")
+	}
+	indexHTML, err := os.ReadFile(filepath.Join(outDir, "index.html"))
+	if err != nil {
+		t.Fatalf("reading index html: %v", err)
+	}
+	if bytes.Contains(indexHTML, []byte("Secret Post")) {
+		t.Error("index page should not list unlisted post")
+	}
+}
+
+func TestBuild_Series(t *testing.T) {
+	if _, err := exec.LookPath("pandoc"); err != nil {
+		t.Skip("pandoc not found in PATH")
+	}
+
+	dir := makeProject(t)
+	mustWrite(t, filepath.Join(dir, "content/posts/part-2.md"), `---
+title: Part Two
+date: 2024-01-01
+series: tutorial
+series_order: 2
+---
+
+第二部分.
+`)
+	mustWrite(t, filepath.Join(dir, "content/posts/part-1.md"), `---
+title: Part One
+date: 2024-05-01
+series: tutorial
+series_order: 1
+---
+
+第一部分.
+`)
+	// 其他分区中同 slug 的文章不是当前文章, 系列名忽略大小写
+	mustWrite(t, filepath.Join(dir, "blog.yaml"), `
+title: Test Blog
+sections:
+  - name: notes
+`)
+	mustWrite(t, filepath.Join(dir, "content/notes/part-2.md"), `---
+title: Note Part
+date: 2024-06-01
+series: Tutorial
+series_order: 3
+---
+
+笔记.
+`)
+	outDir := filepath.Join(dir, "output")
+	if err := build.Run(dir, outDir); err != nil {
+		t.Fatalf("build.Run: %v", err)
+	}
+
+	// series_order 优先于日期
+	landing, err := os.ReadFile(filepath.Join(outDir, "series/tutorial/index.html"))
+	if err != nil {
+		t.Fatalf("reading series page: %v", err)
+	}
+	one, two := bytes.Index(landing, []byte("Part One")), bytes.Index(landing, []byte("Part Two"))
+	if one == -1 || two == -1 || one > two {
+		t.Errorf("series page should list Part One before Part Two")
+	}
+
+	postHTML, err := os.ReadFile(filepath.Join(outDir, "posts/part-2/index.html"))
+	if err != nil {
+		t.Fatalf("reading post html: %v", err)
+	}
+	if !bytes.Contains(postHTML, []byte(`<strong class="current">Part Two</strong>`)) {
+		t.Error("post page should highlight the current part")
+	}
+	if !bytes.Contains(postHTML, []byte(`href="/posts/part-1/"`)) {
+		t.Error("post page should link to the other parts")
+	}
+	if !bytes.Contains(postHTML, []byte(`href="/notes/part-2/"`)) {
+		t.Error("post page should link to a part with the same slug in another section")
+	}
+}
+
+func TestBuild_SeriesUnlisted(t *testing.T) {
+	if _, err := exec.LookPath("pandoc"); err != nil {
+		t.Skip("pandoc not found in PATH")
+	}
+
+	dir := makeProject(t)
+	for name, front := range map[string]string{
+		"part-1": "title: Part One\ndate: 2024-01-01\nseries: tutorial",
+		"part-2": "title: Part Two\ndate: 2024-02-01\nseries: tutorial",
+		"hidden": "title: Hidden Part\ndate: 2024-01-15\nseries: tutorial\nunlisted: true",
+	} {
+		mustWrite(t, filepath.Join(dir, "content/posts", name+".md"), "---\n"+front+"\n---\n\n正文.\n")
+	}
+	outDir := filepath.Join(dir, "output")
+	if err := build.Run(dir, outDir); err != nil {
+		t.Fatalf("build.Run: %v", err)
+	}
+
+	for _, page := range []string{"series/tutorial/index.html", "posts/part-1/index.html"} {
+		html, err := os.ReadFile(filepath.Join(outDir, page))
+		if err != nil {
+			t.Fatalf("reading %s: %v", page, err)
+		}
+		if bytes.Contains(html, []byte("Hidden Part")) {
+			t.Errorf("%s should not list the unlisted part", page)
+		}
+	}
+	hidden, err := os.ReadFile(filepath.Join(outDir, "posts/hidden/index.html"))
+	if err != nil {
+		t.Fatalf("reading unlisted post: %v", err)
+	}
+	one, cur, two := bytes.Index(hidden, []byte("Part One")), bytes.Index(hidden, []byte(`<strong class="current">Hidden Part</strong>`)), bytes.Index(hidden, []byte("Part Two"))
+	if one == -1 || cur == -1 || two == -1 || one > cur || cur > two {
+		t.Error("unlisted post should show itself among the listed parts")
+	}
+}
+
+func TestBuild_TagSlugs(t *testing.T) {
+	if _, err := exec.LookPath("pandoc"); err != nil {
+		t.Skip("pandoc not found in PATH")
+	}
+
+	dir := makeProject(t)
+	mustWrite(t, filepath.Join(dir, "blog.yaml"), `
+title: Test Blog
+nav:
+  tags: tags
+tag_aliases:
+  golang: go
+`)
+	mustWrite(t, filepath.Join(dir, "content/posts/tags.md"), `---
+title: Tricky Tags
+date: 2024-03-01
+tags: [Go, golang, C++, machine learning, ../x, 机器学习]
+---
+
+各种奇怪的 tag.
+`)
+	outDir := filepath.Join(dir, "output")
+	if err := build.Run(dir, outDir); err != nil {
+		t.Fatalf("build.Run: %v", err)
+	}
+
+	for _, rel := range []string{
+		"tags/go/index.html",
+		"tags/c-plus-plus/index.html",
+		"tags/machine-learning/index.html",
+		"tags/x/index.html",
+		"tags/机器学习/index.html",
+	} {
+		if _, err := os.Stat(filepath.Join(outDir, rel)); err != nil {
+			t.Errorf("missing output file: %s", rel)
+		}
+	}
+	for _, rel := range []string{"tags/Go", "tags/golang", "x"} {
+		if _, err := os.Stat(filepath.Join(outDir, rel)); err == nil {
+			t.Errorf("unexpected output: %s", rel)
+		}
+	}
+
+	// Go, golang 和 hello 的 go 合并为同一个 tag 页
+	goHTML, err := os.ReadFile(filepath.Join(outDir, "tags/go/index.html"))
+	if err != nil {
+		t.Fatalf("reading tag html: %v", err)
+	}
+	if !bytes.Contains(goHTML, []byte("Hello World")) || !bytes.Contains(goHTML, []byte("Tricky Tags")) {
+		t.Error("tag page go should list both posts")
+	}
+}
+
+func TestBuild_TagSlugCollision(t *testing.T) {
+	if _, err := exec.LookPath("pandoc"); err != nil {
+		t.Skip("pandoc not found in PATH")
+	}
+
+	dir := makeProject(t)
+	mustWrite(t, filepath.Join(dir, "content/posts/collide.md"), `---
+title: Collide
+date: 2024-03-01
+tags: [a/b, a b]
+---
+
+两个 tag 得到相同的 slug.
+`)
+	if err := build.Run(dir, filepath.Join(dir, "output")); err == nil {
+		t.Fatal("expected error for tag slug collision")
+	}
+}
+
+func TestBuild_TagPages(t *testing.T) {
+	if _, err := exec.LookPath("pandoc"); err != nil {
+		t.Skip("pandoc not found in PATH")
+	}
+
+	dir := makeProject(t)
+	mustWrite(t, filepath.Join(dir, "content/tags/go.md"), `---
+title: The Go Language
+description: Posts about Go.
+---
+
+Go 相关的文章.
+`)
+	mustWrite(t, filepath.Join(dir, "content/tags/go.png"), "png")
+	mustWrite(t, filepath.Join(dir, "content/tags/golnag.md"), "---\ntitle: Typo\n---\n")
+	mustWrite(t, filepath.Join(dir, "content/tags/test.md"), "---\ntitle: Ignored Title\nignore: true\n---\n")
+	mustWrite(t, filepath.Join(dir, "content/posts/go-more.md"), `---
+title: More Go
+date: 2024-03-01
+tags: [go]
+---
+
+另一篇.
+`)
+	outDir := filepath.Join(dir, "output")
+	stderr, err := captureStderr(t, func() error { return build.Run(dir, outDir) })
+	if err != nil {
+		t.Fatalf("build.Run: %v", err)
+	}
+	if want := `warning: content/tags/golnag.md: no post uses tag "golnag"`; !strings.Contains(stderr, want) {
+		t.Errorf("stderr missing %q:\n%s", want, stderr)
+	}
+	if testHTML, _ := os.ReadFile(filepath.Join(outDir, "tags/test/index.html")); bytes.Contains(testHTML, []byte("Ignored Title")) {
+		t.Error("tag page with ignore: true should not be used")
+	}
+
+	tagHTML, err := os.ReadFile(filepath.Join(outDir, "tags/go/index.html"))
+	if err != nil {
+		t.Fatalf("reading tag html: %v", err)
+	}
+	for _, want := range []string{"The Go Language", "Posts about Go.", "Go 相关的文章", `src="/tags/go/cover.png"`} {
+		if !bytes.Contains(tagHTML, []byte(want)) {
+			t.Errorf("tag page missing %q", want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "tags/go/cover.png")); err != nil {
+		t.Error("missing tag cover image")
+	}
+
+	// 默认 tags.html 按文章数倒序: go (2) 在 test (1) 之前
+	tagsHTML, err := os.ReadFile(filepath.Join(outDir, "tags/index.html"))
+	if err != nil {
+		t.Fatalf("reading tags html: %v", err)
+	}
+	goIdx, testIdx := bytes.Index(tagsHTML, []byte("#go")), bytes.Index(tagsHTML, []byte("#test"))
+	if goIdx == -1 || testIdx == -1 || goIdx > testIdx {
+		t.Error("tags page should list go before test")
+	}
+	if !bytes.Contains(tagsHTML, []byte("#go</a> (2)")) {
+		t.Error("tags page should show post count")
+	}
+}
+
+func TestBuild_Authors(t *testing.T) {
+	if _, err := exec.LookPath("pandoc"); err != nil {
+		t.Skip("pandoc not found in PATH")
+	}
+
+	dir := makeProject(t)
+	mustWrite(t, filepath.Join(dir, "blog.yaml"), `
+title: Test Blog
+base_url: https://example.com
+authors:
+  alice:
+    name: Alice Liddell
+    email: alice@example.com
+`)
+	mustWrite(t, filepath.Join(dir, "data/authors.yaml"), `
+bob:
+  name: Bob
+  bio: Bob writes too.
+`)
+	mustWrite(t, filepath.Join(dir, "content/posts/duo.md"), `---
+title: Written Together
+date: 2024-03-01
+authors: [alice, bob]
+---
+
+两位作者.
+`)
+	outDir := filepath.Join(dir, "output")
+	if err := build.Run(dir, outDir); err != nil {
+		t.Fatalf("build.Run: %v", err)
+	}
+
+	bobHTML, err := os.ReadFile(filepath.Join(outDir, "authors/bob/index.html"))
+	if err != nil {
+		t.Fatalf("reading author html: %v", err)
+	}
+	if !bytes.Contains(bobHTML, []byte("Bob writes too.")) || !bytes.Contains(bobHTML, []byte("Written Together")) {
+		t.Error("author page missing bio or post")
+	}
+
+	feed, err := os.ReadFile(filepath.Join(outDir, "authors/alice/feed.xml"))
+	if err != nil {
+		t.Fatalf("reading author feed: %v", err)
+	}
+	for _, want := range []string{
+		"<dc:creator>Alice Liddell</dc:creator>",
+		"<dc:creator>Bob</dc:creator>",
+		"<author>alice@example.com (Alice Liddell)</author>",
+	} {
+		if !bytes.Contains(feed, []byte(want)) {
+			t.Errorf("author feed missing %q", want)
+		}
+	}
+
+	postHTML, err := os.ReadFile(filepath.Join(outDir, "posts/duo/index.html"))
+	if err != nil {
+		t.Fatalf("reading post html: %v", err)
+	}
+	if !bytes.Contains(postHTML, []byte(`href="/authors/alice/"`)) {
+		t.Error("post page should link to author page")
+	}
+}
+
+func TestBuild_Multilingual(t *testing.T) {
+	if _, err := exec.LookPath("pandoc"); err != nil {
+		t.Skip("pandoc not found in PATH")
+	}
+
+	dir := makeProject(t)
+	mustWrite(t, filepath.Join(dir, "blog.yaml"), `
+title: 测试博客
+base_url: https://example.com
+nav:
+  search: 搜索
+  tags: 标签
+languages:
+  - code: zh
+  - code: en
+    title: Test Blog
+    nav:
+      search: Search
+`)
+	mustWrite(t, filepath.Join(dir, "content/posts/hello.en.md"), `---
+title: Hello in English
+date: 2024-01-01
+tags: [go]
+---
+
+English body.
+`)
+	mustWrite(t, filepath.Join(dir, "content/posts/bundle/index.md"), `---
+title: 中文 bundle
+date: 2024-01-03
+---
+
+中文.
+`)
+	mustWrite(t, filepath.Join(dir, "content/posts/bundle/index.en.md"), `---
+title: English bundle
+date: 2024-01-03
+---
+
+English.
+`)
+	outDir := filepath.Join(dir, "output")
+	if err := build.Run(dir, outDir); err != nil {
+		t.Fatalf("build.Run: %v", err)
+	}
+
+	for _, rel := range []string{
+		"posts/hello/index.html",
+		"posts/bundle/index.html",
+		"en/index.html",
+		"en/posts/hello/index.html",
+		"en/posts/bundle/index.html",
+		"en/tags/go/index.html",
+		"en/search.json",
+		"en/feed.xml",
+		"feed.xml",
+		"style.css",
+	} {
+		if _, err := os.Stat(filepath.Join(outDir, rel)); err != nil {
+			t.Errorf("missing output file: %s", rel)
+		}
+	}
+	// 只有中文版的文章不出现在英文站点
+	if _, err := os.Stat(filepath.Join(outDir, "en/posts/math/index.html")); err == nil {
+		t.Error("untranslated post should not be rendered in en")
+	}
+
+	enHTML, err := os.ReadFile(filepath.Join(outDir, "en/posts/hello/index.html"))
+	if err != nil {
+		t.Fatalf("reading post html: %v", err)
+	}
+	for _, want := range []string{
+		`<html lang="en">`,
+		`hreflang="zh" href="https://example.com/posts/hello/"`,
+		`hreflang="en" href="https://example.com/en/posts/hello/"`,
+		`href="/style.css"`,
+		`href="/en/tags/go/"`,
+	} {
+		if !bytes.Contains(enHTML, []byte(want)) {
+			t.Errorf("en post page missing %q", want)
+		}
+	}
+
+	enIndex, err := os.ReadFile(filepath.Join(outDir, "en/index.html"))
+	if err != nil {
+		t.Fatalf("reading en index: %v", err)
+	}
+	if !bytes.Contains(enIndex, []byte("Test Blog")) || !bytes.Contains(enIndex, []byte(">Search<")) {
+		t.Error("en index should use the en title and nav")
+	}
+	if bytes.Contains(enIndex, []byte("Math Post")) {
+		t.Error("en index should not list zh-only posts")
+	}
+}
+
+func TestBuild_Timezone(t *testing.T) {
+	if _, err := exec.LookPath("pandoc"); err != nil {
+		t.Skip("pandoc not found in PATH")
+	}
+
+	dir := makeProject(t)
+	mustWrite(t, filepath.Join(dir, "blog.yaml"), `
+title: Test Blog
+base_url: https://example.com
+timezone: Asia/Shanghai
+`)
+	mustWrite(t, filepath.Join(dir, "content/posts/evening.md"), `---
+title: Evening Post
+date: 2024-03-01 21:30
+updated: 2024-03-05
+---
+
+晚上发布的文章.
+`)
+	outDir := filepath.Join(dir, "output")
+	if err := build.Run(dir, outDir); err != nil {
+		t.Fatalf("build.Run: %v", err)
+	}
+
+	feed, err := os.ReadFile(filepath.Join(outDir, "feed.xml"))
+	if err != nil {
+		t.Fatalf("reading feed: %v", err)
+	}
+	for _, want := range []string{
+		"<pubDate>Fri, 01 Mar 2024 21:30:00 +0800</pubDate>",
+		"<atom:updated>2024-03-05T00:00:00+08:00</atom:updated>",
+	} {
+		if !bytes.Contains(feed, []byte(want)) {
+			t.Errorf("feed missing %q", want)
+		}
+	}
+
+	sitemap, err := os.ReadFile(filepath.Join(outDir, "sitemap.xml"))
+	if err != nil {
+		t.Fatalf("reading sitemap: %v", err)
+	}
+	if !bytes.Contains(sitemap, []byte("<loc>https://example.com/posts/evening/</loc>\n    <lastmod>2024-03-05T00:00:00+08:00</lastmod>")) {
+		t.Error("sitemap should use updated as lastmod")
+	}
+}
+
+func TestBuild_GitInfo(t *testing.T) {
+	if _, err := exec.LookPath("pandoc"); err != nil {
+		t.Skip("pandoc not found in PATH")
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found in PATH")
+	}
+
+	dir := makeProject(t)
+	mustWrite(t, filepath.Join(dir, "blog.yaml"), `
+title: Test Blog
+base_url: https://example.com
+source_url: https://github.com/john/blog/blob/main/{path}
+`)
+	mustWrite(t, filepath.Join(dir, "content/posts/undated.md"), `---
+title: Undated
+history: true
+---
+
+没有写日期的文章.
+`)
+	gitCommit := func(date, msg string) {
+		t.Helper()
+		for _, args := range [][]string{{"add", "-A"}, {"commit", "-q", "-m", msg}} {
+			cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+			cmd.Env = append(os.Environ(),
+				"GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@example.com", "GIT_AUTHOR_DATE="+date,
+				"GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@example.com", "GIT_COMMITTER_DATE="+date)
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("git %v: %v\n%s", args, err, out)
+			}
+		}
+	}
+	if out, err := exec.Command("git", "init", "-q", dir).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v\n%s", err, out)
+	}
+	gitCommit("2024-05-01T10:00:00Z", "add undated post")
+	mustWrite(t, filepath.Join(dir, "content/posts/undated.md"), `---
+title: Undated
+history: true
+---
+
+没有写日期的文章, 修改过.
+`)
+	gitCommit("2024-06-01T10:00:00Z", "fix typo")
+
+	outDir := filepath.Join(dir, "output")
+	if err := build.Run(dir, outDir); err != nil {
+		t.Fatalf("build.Run: %v", err)
+	}
+
+	html, err := os.ReadFile(filepath.Join(outDir, "posts/undated/index.html"))
+	if err != nil {
+		t.Fatalf("reading post: %v", err)
+	}
+	for _, want := range []string{
+		`datetime="2024-05-01T10:00:00Z"`,
+		`datetime="2024-06-01T10:00:00Z"`,
+		"add undated post",
+		"fix typo",
+		`href="https://github.com/john/blog/blob/main/content/posts/undated.md"`,
+	} {
+		if !bytes.Contains(html, []byte(want)) {
+			t.Errorf("post page missing %q", want)
+		}
+	}
+
+	hello, err := os.ReadFile(filepath.Join(outDir, "posts/hello/index.html"))
+	if err != nil {
+		t.Fatalf("reading post: %v", err)
+	}
+	if bytes.Contains(hello, []byte("fix typo")) {
+		t.Error("history should only be shown when enabled in front matter")
+	}
+}
+
+func TestBuild_DatePrefix(t *testing.T) {
+	if _, err := exec.LookPath("pandoc"); err != nil {
+		t.Skip("pandoc not found in PATH")
+	}
+
+	dir := makeProject(t)
+	mustWrite(t, filepath.Join(dir, "content/posts/2024-03-15-jekyll.md"), `---
+title: From Jekyll
+---
+
+迁移过来的文章.
+`)
+	mustWrite(t, filepath.Join(dir, "content/posts/2024-04-bundle/index.md"), `---
+title: Bundle
+date: 2024-04-20
+---
+
+bundle 文章.
+`)
+	outDir := filepath.Join(dir, "output")
+	if err := build.Run(dir, outDir); err != nil {
+		t.Fatalf("build.Run: %v", err)
+	}
+
+	html, err := os.ReadFile(filepath.Join(outDir, "posts/jekyll/index.html"))
+	if err != nil {
+		t.Fatalf("date prefix should be stripped from slug: %v", err)
+	}
+	if !bytes.Contains(html, []byte(`datetime="2024-03-15T00:00:00Z"`)) {
+		t.Error("date should default to the file name date")
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "posts/bundle/index.html")); err != nil {
+		t.Errorf("date prefix should be stripped from bundle slug: %v", err)
+	}
+}
+
+func TestCheck_DatePrefixMismatch(t *testing.T) {
+	if _, err := exec.LookPath("pandoc"); err != nil {
+		t.Skip("pandoc not found in PATH")
+	}
+
+	dir := makeProject(t)
+	mustWrite(t, filepath.Join(dir, "content/posts/2024-03-15-jekyll.md"), `---
+title: From Jekyll
+date: 2024-03-16
+---
+
+日期不一致.
+`)
+	out, err := captureStderr(t, func() error { return build.Check(dir) })
+	if err != nil {
+		t.Fatalf("build.Check: %v", err)
+	}
+	want := "content/posts/2024-03-15-jekyll.md: front matter date 2024-03-16 does not match file name date 2024-03-15"
+	if !strings.Contains(out, want) {
+		t.Errorf("check output missing %q, got:\n%s", want, out)
+	}
+}
+
+// captureStderr 运行 fn, 返回它写到 stderr 的内容.
+func captureStderr(t *testing.T, fn func() error) (string, error) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr := os.Stderr
+	os.Stderr = w
+	err = fn()
+	os.Stderr = stderr
+	w.Close()
+	var out bytes.Buffer
+	out.ReadFrom(r)
+	return out.String(), err
+}
+
+func TestBuild_NestedPosts(t *testing.T) {
+	if _, err := exec.LookPath("pandoc"); err != nil {
+		t.Skip("pandoc not found in PATH")
+	}
+
+	dir := makeProject(t)
+	mustWrite(t, filepath.Join(dir, "content/posts/2019/old.md"), `---
+title: Old Post
+date: 2019-05-01
+---
+
+归档中的文章.
+`)
+	mustWrite(t, filepath.Join(dir, "content/posts/2020/spring/bundled/index.md"), `---
+title: Nested Bundle
+date: 2020-04-01
+---
+
+嵌套的 bundle.
+`)
+	mustWrite(t, filepath.Join(dir, "content/posts/2020/spring/bundled/notes/draft.md"), `---
+title: Not A Post
+date: 2020-04-02
+---
+
+bundle 内的文件不是文章.
+`)
+	outDir := filepath.Join(dir, "output")
+	if err := build.Run(dir, outDir); err != nil {
+		t.Fatalf("build.Run: %v", err)
+	}
+
+	for _, p := range []string{"posts/old/index.html", "posts/bundled/index.html"} {
+		if _, err := os.Stat(filepath.Join(outDir, p)); err != nil {
+			t.Errorf("missing %s: %v", p, err)
+		}
+	}
+	for _, p := range []string{"posts/2019", "posts/draft"} {
+		if _, err := os.Stat(filepath.Join(outDir, p)); err == nil {
+			t.Errorf("%s should not exist", p)
+		}
+	}
+
+	mustWrite(t, filepath.Join(dir, "content/posts/2020/old.md"), `---
+title: Duplicate
+date: 2020-01-01
+---
+
+同名文章.
+`)
+	if err := build.Run(dir, outDir); err == nil {
+		t.Error("expected error for duplicate slug")
+	}
+}
+
+func TestBuild_Sections(t *testing.T) {
+	if _, err := exec.LookPath("pandoc"); err != nil {
+		t.Skip("pandoc not found in PATH")
+	}
+
+	dir := makeProject(t)
+	mustWrite(t, filepath.Join(dir, "blog.yaml"), `
+title: Test Blog
+base_url: https://example.com
+nav:
+  tags: tags
+sections:
+  - name: notes
+    title: Notes
+  - name: projects
+`)
+	mustWrite(t, filepath.Join(dir, "content/notes/hello.md"), `---
+title: Short Note
+date: 2024-03-01
+tags: [go]
+---
+
+一条笔记.
+`)
+	mustWrite(t, filepath.Join(dir, "content/projects/bgen.md"), `---
+title: bgen
+date: 2024-04-01
+---
+
+项目介绍.
+`)
+	mustWrite(t, filepath.Join(dir, "layouts/projects/single.html"), `{{template "base.html" .}}{{define "content"}}<div class="project">{{.Post.Title}}</div>{{end}}`)
+	outDir := filepath.Join(dir, "output")
+	if err := build.Run(dir, outDir); err != nil {
+		t.Fatalf("build.Run: %v", err)
+	}
+
+	for _, p := range []string{
+		"posts/hello/index.html",
+		"notes/hello/index.html",
+		"notes/index.html",
+		"notes/feed.xml",
+		"projects/index.html",
+		"projects/feed.xml",
+	} {
+		if _, err := os.Stat(filepath.Join(outDir, p)); err != nil {
+			t.Errorf("missing %s: %v", p, err)
+		}
+	}
+
+	index, _ := os.ReadFile(filepath.Join(outDir, "index.html"))
+	if bytes.Contains(index, []byte("Short Note")) {
+		t.Error("home page should only list posts")
+	}
+	feed, _ := os.ReadFile(filepath.Join(outDir, "feed.xml"))
+	if bytes.Contains(feed, []byte("Short Note")) {
+		t.Error("site feed should only contain posts")
+	}
+	notesFeed, _ := os.ReadFile(filepath.Join(outDir, "notes/feed.xml"))
+	if !bytes.Contains(notesFeed, []byte("<link>https://example.com/notes/hello/</link>")) {
+		t.Error("notes feed should contain the note")
+	}
+	tag, _ := os.ReadFile(filepath.Join(outDir, "tags/go/index.html"))
+	if !bytes.Contains(tag, []byte("/notes/hello/")) {
+		t.Error("tag pages should include entries from all sections")
+	}
+	project, _ := os.ReadFile(filepath.Join(outDir, "projects/bgen/index.html"))
+	if !bytes.Contains(project, []byte(`<div class="project">bgen</div>`)) {
+		t.Error("section single template should be used")
+	}
+}
+
+func TestBuild_ReservedSections(t *testing.T) {
+	for _, tt := range []struct{ yaml, want string }{
+		{"sections:\n  - name: archives\n", `section name "archives" is reserved`},
+		{"languages:\n  - code: zh\n  - code: en\nsections:\n  - name: en\n", `section name "en" is a language code`},
+	} {
+		dir := makeProject(t)
+		mustWrite(t, filepath.Join(dir, "blog.yaml"), "title: Test Blog\n"+tt.yaml)
+		err := build.Run(dir, filepath.Join(dir, "output"))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("expected %q, got %v", tt.want, err)
+		}
+	}
+}
+
+func TestBuild_NestedPages(t *testing.T) {
+	if _, err := exec.LookPath("pandoc"); err != nil {
+		t.Skip("pandoc not found in PATH")
+	}
+
+	dir := makeProject(t)
+	if err := os.Remove(filepath.Join(dir, "content/about.md")); err != nil {
+		t.Fatal(err)
+	}
+	mustWrite(t, filepath.Join(dir, "content/about/index.md"), `---
+title: About
+toc: false
+---
+
+## 我
+
+![photo](me.png)
+`)
+	mustWrite(t, filepath.Join(dir, "content/about/me.png"), "png")
+	mustWrite(t, filepath.Join(dir, "content/docs/index.md"), `---
+title: Docs
+---
+
+文档首页.
+`)
+	mustWrite(t, filepath.Join(dir, "content/docs/install.md"), `---
+title: Install
+summary: 安装方法
+---
+
+安装.
+`)
+	outDir := filepath.Join(dir, "output")
+	if err := build.Run(dir, outDir); err != nil {
+		t.Fatalf("build.Run: %v", err)
+	}
+
+	for _, p := range []string{"about/index.html", "about/me.png", "docs/index.html", "docs/install/index.html"} {
+		if _, err := os.Stat(filepath.Join(outDir, p)); err != nil {
+			t.Errorf("missing %s: %v", p, err)
+		}
+	}
+
+	about, _ := os.ReadFile(filepath.Join(outDir, "about/index.html"))
+	if bytes.Contains(about, []byte(`class="toc"`)) {
+		t.Error("toc: false should hide the table of contents")
+	}
+	docs, _ := os.ReadFile(filepath.Join(outDir, "docs/index.html"))
+	if !bytes.Contains(docs, []byte(`href="/docs/install/">Install</a> <span class="summary">安装方法</span>`)) {
+		t.Error("parent page should list its children")
+	}
+	install, _ := os.ReadFile(filepath.Join(outDir, "docs/install/index.html"))
+	if !bytes.Contains(install, []byte(`<a href="/docs/">← Docs</a>`)) {
+		t.Error("child page should link to its parent")
+	}
+	index, _ := os.ReadFile(filepath.Join(outDir, "index.html"))
+	if bytes.Contains(index, []byte(`href="/docs/install/"`)) {
+		t.Error("nested pages should not appear in the nav")
+	}
+}
+
+func TestBuild_Menu(t *testing.T) {
+	if _, err := exec.LookPath("pandoc"); err != nil {
+		t.Skip("pandoc not found in PATH")
+	}
+
+	dir := makeProject(t)
+	mustWrite(t, filepath.Join(dir, "blog.yaml"), `
+title: Test Blog
+base_url: https://example.com
+menu:
+  - label: GitHub
+    url: https://github.com/john
+    weight: 30
+  - section: archives
+    weight: 10
+  - page: about
+    weight: 20
+    children:
+      - page: about/contact
+`)
+	mustWrite(t, filepath.Join(dir, "content/about/contact.md"), `---
+title: Contact
+---
+
+联系方式.
+`)
+	mustWrite(t, filepath.Join(dir, "content/hidden.md"), `---
+title: Hidden
+menu: false
+---
+
+不在菜单中.
+`)
+	outDir := filepath.Join(dir, "output")
+	if err := build.Run(dir, outDir); err != nil {
+		t.Fatalf("build.Run: %v", err)
+	}
+
+	contact, err := os.ReadFile(filepath.Join(outDir, "about/contact/index.html"))
+	if err != nil {
+		t.Fatalf("reading page: %v", err)
+	}
+	archives := bytes.Index(contact, []byte(`<a href="/archives/">归档</a>`))
+	about := bytes.Index(contact, []byte(`<a href="/about/" class="active">About</a>`))
+	github := bytes.Index(contact, []byte(`<a href="https://github.com/john">GitHub</a>`))
+	if archives == -1 || about == -1 || github == -1 {
+		t.Fatalf("menu items missing:\n%s", contact)
+	}
+	if !(archives < about && about < github) {
+		t.Error("menu items should be ordered by weight")
+	}
+	if !bytes.Contains(contact, []byte(`<a href="/about/contact/" class="active">Contact</a>`)) {
+		t.Error("current page should be active in the submenu")
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "archives/index.html")); err != nil {
+		t.Errorf("archives referenced by the menu should be generated: %v", err)
+	}
+
+	// 默认菜单跳过 menu: false 的页面
+	mustWrite(t, filepath.Join(dir, "blog.yaml"), "title: Test Blog\n")
+	if err := build.Run(dir, outDir); err != nil {
+		t.Fatalf("build.Run: %v", err)
+	}
+	index, _ := os.ReadFile(filepath.Join(outDir, "index.html"))
+	if !bytes.Contains(index, []byte(`href="/about/"`)) || bytes.Contains(index, []byte(`href="/hidden/"`)) {
+		t.Error("default menu should list top-level pages except those with menu: false")
+	}
+
+	mustWrite(t, filepath.Join(dir, "blog.yaml"), "title: Test Blog\nmenu:\n  - page: missing\n")
+	if err := build.Run(dir, outDir); err == nil {
+		t.Error("expected error for menu item pointing to a missing page")
+	}
+
+	// 其他语言缺少翻译的页面被跳过; posts 在文章页上激活
+	mustWrite(t, filepath.Join(dir, "blog.yaml"), `
+title: Test Blog
+languages:
+  - code: zh
+  - code: en
+menu:
+  - section: posts
+    label: Blog
+  - page: about/contact
+`)
+	stderr, err := captureStderr(t, func() error { return build.Run(dir, outDir) })
+	if err != nil {
+		t.Fatalf("build.Run: %v", err)
+	}
+	if want := `warning: blog.yaml: menu: page "about/contact" has no en version, skipped`; !strings.Contains(stderr, want) {
+		t.Errorf("stderr missing %q:\n%s", want, stderr)
+	}
+	enIndex, _ := os.ReadFile(filepath.Join(outDir, "en/index.html"))
+	if bytes.Contains(enIndex, []byte("/about/contact/")) {
+		t.Error("en menu should skip the untranslated page")
+	}
+	post, _ := os.ReadFile(filepath.Join(outDir, "posts/hello/index.html"))
+	if !bytes.Contains(post, []byte(`<a href="/" class="active">Blog</a>`)) || !bytes.Contains(post, []byte(`href="/about/contact/"`)) {
+		t.Errorf("posts menu item should be active on post pages:\n%s", post)
+	}
+}
+
+func TestBuild_ListIndex(t *testing.T) {
+	if _, err := exec.LookPath("pandoc"); err != nil {
+		t.Skip("pandoc not found in PATH")
+	}
+
+	dir := makeProject(t)
+	mustWrite(t, filepath.Join(dir, "blog.yaml"), `
+title: Test Blog
+hero:
+  header: YAML Hero
+nav:
+  tags: tags
+sections:
+  - name: notes
+`)
+	mustWrite(t, filepath.Join(dir, "content/_index.md"), `---
+title: Markdown Hero
+---
+
+欢迎来到 [我的博客](https://example.com).
+`)
+	mustWrite(t, filepath.Join(dir, "content/tags/_index.md"), `---
+title: All Tags
+---
+
+按主题浏览.
+`)
+	mustWrite(t, filepath.Join(dir, "content/notes/_index.md"), `---
+title: Short Notes
+description: 零碎的想法
+---
+
+笔记列表.
+`)
+	mustWrite(t, filepath.Join(dir, "content/notes/first.md"), `---
+title: First Note
+date: 2024-03-01
+---
+
+第一条.
+`)
+	outDir := filepath.Join(dir, "output")
+	if err := build.Run(dir, outDir); err != nil {
+		t.Fatalf("build.Run: %v", err)
+	}
+
+	checks := map[string][]string{
+		"index.html":       {"<h1>Markdown Hero</h1>", `<a href="https://example.com">我的博客</a>`},
+		"tags/index.html":  {"<h1>All Tags</h1>", "按主题浏览."},
+		"notes/index.html": {"<h1>Short Notes</h1>", "零碎的想法", "笔记列表.", "First Note"},
+	}
+	for path, wants := range checks {
+		html, err := os.ReadFile(filepath.Join(outDir, path))
+		if err != nil {
+			t.Fatalf("reading %s: %v", path, err)
+		}
+		for _, want := range wants {
+			if !bytes.Contains(html, []byte(want)) {
+				t.Errorf("%s missing %q", path, want)
+			}
+		}
+	}
+	index, _ := os.ReadFile(filepath.Join(outDir, "index.html"))
+	if bytes.Contains(index, []byte("YAML Hero")) {
+		t.Error("_index.md should replace the YAML hero")
+	}
+	for _, p := range []string{"_index/index.html", "notes/_index/index.html", "tags/index/index.html"} {
+		if _, err := os.Stat(filepath.Join(outDir, p)); err == nil {
+			t.Errorf("%s should not be generated", p)
+		}
+	}
+}
+
+func TestBuild_Data(t *testing.T) {
+	if _, err := exec.LookPath("pandoc"); err != nil {
+		t.Skip("pandoc not found in PATH")
+	}
+
+	dir := makeProject(t)
+	mustWrite(t, filepath.Join(dir, "data/talks.yaml"), `
+- title: Go at Scale
+  year: 2023
+- title: Writing a Blog Generator
+  year: 2024
+`)
+	mustWrite(t, filepath.Join(dir, "data/links/friends.json"), `[{"name": "Alice", "url": "https://alice.example.com"}]`)
+	mustWrite(t, filepath.Join(dir, "content/talks.md"), `---
+title: Talks
+layout: talks
+---
+`)
+	mustWrite(t, filepath.Join(dir, "layouts/talks.html"), `{{template "base.html" .}}{{define "content"}}<ul>{{range .Site.Data.talks}}<li>{{.year}} {{.title}}</li>{{end}}</ul>{{range .Site.Data.links.friends}}<a href="{{.url}}">{{.name}}</a>{{end}}{{end}}`)
+	outDir := filepath.Join(dir, "output")
+	if err := build.Run(dir, outDir); err != nil {
+		t.Fatalf("build.Run: %v", err)
+	}
+
+	html, err := os.ReadFile(filepath.Join(outDir, "talks/index.html"))
+	if err != nil {
+		t.Fatalf("reading page: %v", err)
+	}
+	for _, want := range []string{"<li>2023 Go at Scale</li>", "<li>2024 Writing a Blog Generator</li>", `<a href="https://alice.example.com">Alice</a>`} {
+		if !bytes.Contains(html, []byte(want)) {
+			t.Errorf("page missing %q", want)
+		}
+	}
+
+	// data 的错误和内容的错误一起报告
+	mustWrite(t, filepath.Join(dir, "data/talks.yaml"), "- title: [\n")
+	mustWrite(t, filepath.Join(dir, "content/posts/broken.md"), "---\ntitle: [\n---\n")
+	stderr, err := captureStderr(t, func() error { return build.Run(dir, outDir) })
+	if err == nil || !strings.Contains(stderr, "data/talks.yaml: yaml: line") || !strings.Contains(stderr, "content/posts/broken.md") {
+		t.Errorf("expected data and content errors, got %v:\n%s", err, stderr)
+	}
+}
+
+func TestBuild_Params(t *testing.T) {
+	if _, err := exec.LookPath("pandoc"); err != nil {
+		t.Skip("pandoc not found in PATH")
+	}
+
+	dir := makeProject(t)
+	mustWrite(t, filepath.Join(dir, "blog.yaml"), `
+title: Test Blog
+params:
+  mastodon: "@john@example.social"
+`)
+	mustWrite(t, filepath.Join(dir, "content/posts/hello.md"), `---
+title: Hello World
+date: 2024-01-01
+accent: teal
+---
+
+正文.
+`)
+	mustWrite(t, filepath.Join(dir, "layouts/single.html"), `{{template "base.html" .}}{{define "content"}}<p style="color: {{.Post.Params.accent}}">{{.Site.Params.mastodon}}</p>{{if .Post.Params.hide_cover}}hidden{{end}}{{end}}`)
+	outDir := filepath.Join(dir, "output")
+	if err := build.Run(dir, outDir); err != nil {
+		t.Fatalf("build.Run: %v", err)
+	}
+
+	html, err := os.ReadFile(filepath.Join(outDir, "posts/hello/index.html"))
+	if err != nil {
+		t.Fatalf("reading post: %v", err)
+	}
+	if !bytes.Contains(html, []byte(`<p style="color: teal">@john@example.social</p>`)) {
+		t.Errorf("params not rendered:\n%s", html)
+	}
+}
+
+func TestCheck_UnknownKeys(t *testing.T) {
+	if _, err := exec.LookPath("pandoc"); err != nil {
+		t.Skip("pandoc not found in PATH")
+	}
+
+	dir := makeProject(t)
+	mustWrite(t, filepath.Join(dir, "blog.yaml"), `title: Test Blog
+base-url: https://example.com
+`)
+	mustWrite(t, filepath.Join(dir, "content/posts/typo.md"), `---
+title: Typo
+date: 2024-03-01
+tag: [go]
+accent: teal
+---
+
+正文.
+`)
+	mustWrite(t, filepath.Join(dir, "content/posts/untitled.md"), `---
+summary: 没有标题和日期
+---
+
+正文.
+`)
+	out, err := captureStderr(t, func() error { return build.Check(dir) })
+	if err != nil {
+		t.Fatalf("build.Check: %v", err)
+	}
+	for _, want := range []string{
+		`warning: blog.yaml: line 2: unknown key "base-url", did you mean "base_url"?`,
+		`warning: content/posts/typo.md: line 4: unknown key "tag", did you mean "tags"?`,
+		`warning: content/posts/untitled.md: post has no title`,
+		`warning: content/posts/untitled.md: post has no date`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("check output missing %q, got:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "accent") {
+		t.Errorf("custom param reported as unknown key:\n%s", out)
+	}
+}
+
+func TestBuild_WrongType(t *testing.T) {
+	if _, err := exec.LookPath("pandoc"); err != nil {
+		t.Skip("pandoc not found in PATH")
+	}
+
+	dir := makeProject(t)
+	mustWrite(t, filepath.Join(dir, "content/posts/hello.md"), `---
+title: Hello World
+date: 2024-01-01
+tags: go
+---
+
+正文.
+`)
+	outDir := filepath.Join(dir, "output")
+	out, err := captureStderr(t, func() error { return build.Run(dir, outDir) })
+	if err == nil {
+		t.Fatal("expected error for string tags")
+	}
+	want := `error: content/posts/hello.md: front matter: line 4: "tags": expected a list, got a string`
+	if !strings.Contains(out, want) {
+		t.Errorf("build output missing %q, got:\n%s", want, out)
+	}
+	if _, err := os.Stat(outDir); !os.IsNotExist(err) {
+		t.Error("output written despite errors")
+	}
+}
+
+func TestBuild_Strict(t *testing.T) {
+	if _, err := exec.LookPath("pandoc"); err != nil {
+		t.Skip("pandoc not found in PATH")
+	}
+
+	dir := makeProject(t)
+	mustWrite(t, filepath.Join(dir, "content/posts/untitled.md"), `---
+date: 2024-03-01
+---
+
+正文.
+`)
+	outDir := filepath.Join(dir, "output")
+	if _, err := captureStderr(t, func() error { return build.Run(dir, outDir) }); err != nil {
+		t.Fatalf("build.Run without strict: %v", err)
+	}
+
+	mustWrite(t, filepath.Join(dir, "blog.yaml"), `
+title: Test Blog
+strict: true
+`)
+	strictDir := filepath.Join(dir, "strict-output")
+	_, err := captureStderr(t, func() error { return build.Run(dir, strictDir) })
+	if err == nil || !strings.Contains(err.Error(), "strict") {
+		t.Errorf("build.Run with strict: got %v", err)
+	}
+	if _, err := os.Stat(strictDir); err == nil {
+		t.Error("build.Run with strict should not write output")
+	}
+	_, err = captureStderr(t, func() error { return build.Check(dir) })
+	if err == nil {
+		t.Error("build.Check with strict: expected error")
+	}
+}
+
+// fakePandoc 遇到 FAIL_HERE 时像 pandoc 一样报告第 3 行出错, 其他输入输出固定的 HTML.
+const fakePandoc = `#!/bin/sh
+input=$(cat)
+case "$input" in
+*FAIL_HERE*)
+	echo 'Error at "stdin" (line 3, column 1):' >&2
+	echo 'unexpected FAIL_HERE' >&2
+	exit 64 ;;
+esac
+echo '<html><body><p>ok</p></body></html>'
+`
+
+func TestBuild_ReportsAllErrors(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake pandoc is a shell script")
+	}
+	bin := t.TempDir()
+	mustWrite(t, filepath.Join(bin, "pandoc"), fakePandoc)
+	if err := os.Chmod(filepath.Join(bin, "pandoc"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	dir := makeProject(t)
+	mustWrite(t, filepath.Join(dir, "content/posts/bad-pandoc.md"), `---
+title: Bad Pandoc
+date: 2024-03-01
+---
+
+第一段.
+
+FAIL_HERE
+`)
+	mustWrite(t, filepath.Join(dir, "content/posts/bad-type.md"), `---
+title: Bad Type
+date: 2024-03-02
+tags: go
+---
+`)
+	mustWrite(t, filepath.Join(dir, "content/posts/bad-yaml.md"), `---
+title: Bad YAML
+date: 2024-03-03
+summary: a: b
+---
+`)
+	mustWrite(t, filepath.Join(dir, "content/broken.md"), "没有 front matter.\n")
+	mustWrite(t, filepath.Join(dir, "layouts/page.html"), `{{define "content"}}{{.Page.Title}{{end}}`)
+
+	outDir := filepath.Join(dir, "output")
+	out, err := captureStderr(t, func() error { return build.Run(dir, outDir) })
+	if err == nil {
+		t.Fatal("expected build to fail")
+	}
+	wants := []string{
+		"error: loading template page: template: page.html:1:",
+		"error: content/broken.md: missing front matter",
+		"error: content/posts/bad-pandoc.md: line 8: pandoc: Error at \"stdin\" (line 3, column 1):",
+		"  >    8 | FAIL_HERE",
+		`error: content/posts/bad-type.md: front matter: line 4: "tags": expected a list, got a string`,
+		"error: content/posts/bad-yaml.md: front matter: line 4: mapping values are not allowed",
+	}
+	last := -1
+	for _, want := range wants {
+		i := strings.Index(out, want)
+		if i == -1 {
+			t.Errorf("build output missing %q, got:\n%s", want, out)
+			continue
+		}
+		if i < last {
+			t.Errorf("%q is out of order in:\n%s", want, out)
+		}
+		last = i
+	}
+	if _, err := os.Stat(outDir); !os.IsNotExist(err) {
+		t.Error("output written despite errors")
+	}
+}
+
+func TestBuild_OutputCollisions(t *testing.T) {
+	if _, err := exec.LookPath("pandoc"); err != nil {
+		t.Skip("pandoc not found in PATH")
+	}
+
+	dir := makeProject(t)
+	mustWrite(t, filepath.Join(dir, "content/posts/hello/index.md"), `---
+title: Hello Bundle
+date: 2024-03-01
+---
+
+与 hello.md 同名的 bundle.
+`)
+	mustWrite(t, filepath.Join(dir, "content/tags.md"), `---
+title: Tags
+---
+
+与 tag 列表页冲突.
+`)
+	outDir := filepath.Join(dir, "output")
+	stderr, err := captureStderr(t, func() error { return build.Run(dir, outDir) })
+	if err == nil {
+		t.Fatal("expected error for output collisions")
+	}
+	for _, want := range []string{
+		"error: output posts/hello/index.html is written by both content/posts/hello.md and content/posts/hello/index.md",
+		"error: output tags/index.html is written by both content/tags.md and the tags page",
+	} {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("stderr missing %q:\n%s", want, stderr)
+		}
+	}
+	if _, err := os.Stat(outDir); err == nil {
+		t.Error("output should not be written when outputs collide")
+	}
+}
+
+func TestBuild_StaticOverrides(t *testing.T) {
+	if _, err := exec.LookPath("pandoc"); err != nil {
+		t.Skip("pandoc not found in PATH")
+	}
+
+	dir := makeProject(t)
+	mustWrite(t, filepath.Join(dir, "static/about/index.html"), "<p>static about</p>")
+	outDir := filepath.Join(dir, "output")
+	stderr, err := captureStderr(t, func() error { return build.Run(dir, outDir) })
+	if err == nil || !strings.Contains(stderr, "output about/index.html is written by both content/about.md and static/about/index.html") {
+		t.Fatalf("expected static collision, got %v:\n%s", err, stderr)
+	}
+
+	mustWrite(t, filepath.Join(dir, "blog.yaml"), `
+title: Test Blog
+static_overrides: true
+`)
+	if err := build.Run(dir, outDir); err != nil {
+		t.Fatalf("build.Run with static_overrides: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(outDir, "about/index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "<p>static about</p>" {
+		t.Errorf("static file should override the page, got:\n%s", data)
+	}
+}
+
+func TestBuild_HostilePaths(t *testing.T) {
+	if _, err := exec.LookPath("pandoc"); err != nil {
+		t.Skip("pandoc not found in PATH")
+	}
+
+	secret := filepath.Join(t.TempDir(), "secret.png")
+	mustWrite(t, secret, "secret")
+
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{
+			name: "image outside the bundle",
+			files: map[string]string{"content/posts/evil/index.md": `---
+title: Evil
+date: 2024-03-01
+---
+
+![x](../../../../../../../../etc/hosts)
+`},
+			want: `content/posts/evil/index.md: line 6: image "../../../../../../../../etc/hosts" is outside the bundle`,
+		},
+		{
+			name: "image escaping through a subdirectory",
+			files: map[string]string{
+				"content/posts/evil/index.md":  "---\ntitle: Evil\ndate: 2024-03-01\n---\n\n![x](img/../../../hello.md)\n",
+				"content/posts/evil/img/a.png": "png",
+			},
+			want: `content/posts/evil/index.md: line 6: image "img/../../../hello.md" is outside the bundle`,
+		},
+		{
+			name: "slug with ..",
+			files: map[string]string{"content/posts/evil.md": `---
+title: Evil
+date: 2024-03-01
+slug: ../../../escape
+---
+
+正文.
+`},
+			want: `content/posts/evil.md: slug "../../../escape" must be a single path segment`,
+		},
+		{
+			name: "layout outside layouts/",
+			files: map[string]string{"content/evil.md": `---
+title: Evil
+layout: ../../outside
+---
+
+正文.
+`},
+			want: `content/evil.md: layout "../../outside" must be a name under layouts/`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := makeProject(t)
+			for name, content := range tt.files {
+				mustWrite(t, filepath.Join(dir, name), content)
+			}
+			outDir := filepath.Join(dir, "output")
+			stderr, err := captureStderr(t, func() error { return build.Run(dir, outDir) })
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(stderr, tt.want) {
+				t.Errorf("stderr missing %q:\n%s", tt.want, stderr)
+			}
+			if _, err := os.Stat(filepath.Join(dir, "escape")); err == nil {
+				t.Error("file written outside the output directory")
+			}
+		})
+	}
+
+	symlinks := []struct {
+		name string
+		md   string // 使用符号链接的 markdown 文件
+		link string
+		want string
+	}{
+		{"bundle image", "content/posts/evil/index.md", "content/posts/evil/leak.png",
+			`"leak.png" links to a file outside content/posts/evil/`},
+		{"post cover", "content/posts/hello.md", "content/posts/hello.jpg",
+			`content/posts/hello.md: cover "hello.jpg" links to a file outside content/posts/`},
+		{"tag cover", "content/tags/go.md", "content/tags/go.png",
+			`content/tags/go.md: cover "go.png" links to a file outside content/tags/`},
+		{"static file", "", "static/leak.txt", `static file "leak.txt" links to a file outside static/`},
+		{"data file", "", "data/leak.json", `data/leak.json: links to a file outside data/`},
+	}
+	for _, tt := range symlinks {
+		t.Run("symlink as "+tt.name, func(t *testing.T) {
+			if runtime.GOOS == "windows" {
+				t.Skip("symlinks need privileges on windows")
+			}
+			dir := makeProject(t)
+			if _, err := os.Stat(filepath.Join(dir, tt.md)); tt.md != "" && err != nil {
+				mustWrite(t, filepath.Join(dir, tt.md), "---\ntitle: Evil\ndate: 2024-03-01\n---\n\n![x](leak.png)\n")
+			}
+			if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, tt.link)), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Symlink(secret, filepath.Join(dir, tt.link)); err != nil {
+				t.Fatal(err)
+			}
+			outDir := filepath.Join(dir, "output")
+			stderr, err := captureStderr(t, func() error { return build.Run(dir, outDir) })
+			if err == nil || !strings.Contains(stderr, tt.want) {
+				t.Errorf("expected symlink error, got %v:\n%s", err, stderr)
+			}
+			if _, err := os.Stat(outDir); err == nil {
+				t.Error("output should not be written")
+			}
+		})
+	}
+
+	t.Run("tag with path characters", func(t *testing.T) {
+		dir := makeProject(t)
+		mustWrite(t, filepath.Join(dir, "content/posts/tagged.md"), `---
+title: Tagged
+date: 2024-03-01
+tags: ["../../escape"]
+---
+
+正文.
+`)
+		outDir := filepath.Join(dir, "output")
+		if err := build.Run(dir, outDir); err != nil {
+			t.Fatalf("build.Run: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(outDir, "tags/escape/index.html")); err != nil {
+			t.Errorf("tag page should stay under tags/: %v", err)
+		}
+	})
+}
+
+func TestBuild_BundleFiles(t *testing.T) {
+	if _, err := exec.LookPath("pandoc"); err != nil {
+		t.Skip("pandoc not found in PATH")
+	}
+
+	dir := makeProject(t)
+	mustWrite(t, filepath.Join(dir, "blog.yaml"), `
+title: Test Blog
+bundle_exclude: ["*.psd", drafts]
+`)
+	mustWrite(t, filepath.Join(dir, "content/posts/talk/index.md"), `---
+title: Talk
+date: 2024-03-01
+---
+
+[幻灯片](./slides.pdf), [数据](data/result.csv)
+
+<video src="demo.mp4"></video>
+
+[源文件](cover.psd) 和 [丢失的文件](missing.zip)
+
+`+"```"+`
+[代码块中的链接](not-checked.zip)
+`+"```"+`
+`)
+	for _, name := range []string{"slides.pdf", "data/result.csv", "demo.mp4", "style.css", "cover.psd", "drafts/notes.txt", ".DS_Store"} {
+		mustWrite(t, filepath.Join(dir, "content/posts/talk", name), name)
+	}
+	outDir := filepath.Join(dir, "output")
+	stderr, err := captureStderr(t, func() error { return build.Run(dir, outDir) })
+	if err != nil {
+		t.Fatalf("build.Run: %v", err)
+	}
+
+	for _, name := range []string{"slides.pdf", "data/result.csv", "demo.mp4", "style.css"} {
+		if _, err := os.Stat(filepath.Join(outDir, "posts/talk", name)); err != nil {
+			t.Errorf("bundle file %s not copied: %v", name, err)
+		}
+	}
+	for _, name := range []string{"index.md", "cover.psd", "drafts/notes.txt", ".DS_Store"} {
+		if _, err := os.Stat(filepath.Join(outDir, "posts/talk", name)); err == nil {
+			t.Errorf("%s should not be copied", name)
+		}
+	}
+	for _, want := range []string{
+		`warning: content/posts/talk/index.md: line 10: links to "cover.psd", which is excluded by bundle_exclude`,
+		`warning: content/posts/talk/index.md: line 10: links to "missing.zip", which does not exist in the bundle`,
+	} {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("stderr missing %q:\n%s", want, stderr)
+		}
+	}
+	if strings.Contains(stderr, "not-checked.zip") {
+		t.Errorf("links in code blocks should not be checked:\n%s", stderr)
+	}
+}
+
+func TestBuild_MissingImages(t *testing.T) {
+	if _, err := exec.LookPath("pandoc"); err != nil {
+		t.Skip("pandoc not found in PATH")
+	}
+
+	dir := makeProject(t)
+	mustWrite(t, filepath.Join(dir, "content/posts/flat.md"), `---
+title: Flat
+date: 2024-03-01
+---
+
+![存在的图片](./images/foo.png)
+
+![丢失的图片](images/missing.png)
+`)
+	mustWrite(t, filepath.Join(dir, "content/posts/images/foo.png"), "png")
+	mustWrite(t, filepath.Join(dir, "content/posts/bundled/index.md"), `---
+title: Bundled
+date: 2024-03-02
+---
+
+正文.
+
+<img src="gone.jpg" alt="丢失的图片">
+`)
+	outDir := filepath.Join(dir, "output")
+	stderr, err := captureStderr(t, func() error { return build.Run(dir, outDir) })
+	if err != nil {
+		t.Fatalf("build.Run: %v", err)
+	}
+	for _, want := range []string{
+		`warning: content/posts/flat.md: line 8: image "images/missing.png" does not exist`,
+		`warning: content/posts/bundled/index.md: line 8: image "gone.jpg" does not exist`,
+	} {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("build stderr missing %q:\n%s", want, stderr)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "posts/flat/images/foo.png")); err != nil {
+		t.Errorf("image of flat post not copied: %v", err)
+	}
+
+	stderr, err = captureStderr(t, func() error { return build.Check(dir) })
+	if err == nil {
+		t.Error("build.Check: expected error for missing images")
+	}
+	if want := `error: content/posts/flat.md: line 8: image "images/missing.png" does not exist`; !strings.Contains(stderr, want) {
+		t.Errorf("check stderr missing %q:\n%s", want, stderr)
+	}
+}
+
+func TestCheck_Links(t *testing.T) {
+	if _, err := exec.LookPath("pandoc"); err != nil {
+		t.Skip("pandoc not found in PATH")
+	}
+
+	dir := makeProject(t)
+	mustWrite(t, filepath.Join(dir, "blog.yaml"), `
+title: Test Blog
+base_url: https://example.com/blog
+nav:
+  tags: tags
+`)
+	mustWrite(t, filepath.Join(dir, "content/posts/links.md"), `---
+title: Links
+date: 2024-03-01
+---
+
+[好的链接](/blog/posts/hello/) 和 [改名的文章](/blog/posts/old-slug/)
+
+[不存在的锚点](/blog/about/#contact)
+`)
+	stderr, err := captureStderr(t, func() error { return build.CheckLinks(dir) })
+	if err == nil || !strings.Contains(err.Error(), "2 broken link(s) in 1 page(s)") {
+		t.Fatalf("build.CheckLinks: got %v", err)
+	}
+	want := `posts/links/index.html (content/posts/links.md):
+  /blog/posts/old-slug/: not found
+  /blog/about/#contact: no element with id "contact" in about/index.html
+`
+	if !strings.Contains(stderr, want) {
+		t.Errorf("stderr missing %q:\n%s", want, stderr)
+	}
+	if strings.Contains(stderr, "/blog/posts/hello/") {
+		t.Errorf("valid link reported:\n%s", stderr)
+	}
+
+	// check_links: true 时 build 也检查链接
+	mustWrite(t, filepath.Join(dir, "blog.yaml"), `
+title: Test Blog
+base_url: https://example.com/blog
+nav:
+  tags: tags
+check_links: true
+`)
+	// 输出目录中旧的页面不能掩盖坏链接
+	outDir := filepath.Join(dir, "output")
+	mustWrite(t, filepath.Join(outDir, "posts/old-slug/index.html"), "<p>old</p>")
+	stderr, err = captureStderr(t, func() error { return build.Run(dir, outDir) })
+	if err == nil || !strings.Contains(stderr, "/blog/posts/old-slug/: not found") {
+		t.Errorf("build.Run with check_links: got %v:\n%s", err, stderr)
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "posts/links/index.html")); err == nil {
+		t.Error("output should not be written when links are broken")
+	}
+
+	mustWrite(t, filepath.Join(dir, "content/posts/links.md"), "---\ntitle: Links\ndate: 2024-03-01\n---\n\n[好的链接](/blog/posts/hello/)\n")
+	if _, err := captureStderr(t, func() error { return build.Run(dir, outDir) }); err != nil {
+		t.Fatalf("build.Run: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "posts/links/index.html")); err != nil {
+		t.Errorf("output not written: %v", err)
+	}
+}
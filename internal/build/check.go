@@ -0,0 +1,112 @@
+package build
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/zhhc99/bgen/internal/config"
+	"github.com/zhhc99/bgen/internal/l10n"
+	"github.com/zhhc99/bgen/internal/site"
+	"github.com/zhhc99/bgen/internal/validate"
+)
+
+// Check 检查配置和内容中的问题并打印.
+func Check(projectRoot string) error {
+	_, _, err := check(projectRoot, "")
+	return err
+}
+
+// check 做 Check 的检查, outDir 非空时同时构建站点.
+func check(projectRoot, outDir string) (*config.Config, *site.Result, error) {
+	cfg, warnings, err := prepare(projectRoot)
+	if err != nil {
+		return nil, nil, err
+	}
+	more := checkL10n(cfg)
+	res, err := site.Check(cfg, projectRoot, outDir)
+	more = append(more, res.Warnings...)
+	printWarnings(more)
+	if err != nil {
+		return nil, nil, siteError("loading content", err)
+	}
+	warnings = append(warnings, more...)
+	fmt.Printf("check complete: %d warning(s)\n", len(warnings))
+	return cfg, res, checkStrict(cfg, warnings)
+}
+
+// prepare 检查并读取 blog.yaml.
+func prepare(projectRoot string) (*config.Config, []string, error) {
+	warnings, errs := lint(projectRoot)
+	printWarnings(warnings)
+	for _, e := range errs {
+		fmt.Fprintf(os.Stderr, "error: %s\n", e)
+	}
+	if len(errs) > 0 {
+		return nil, nil, fmt.Errorf("%d error(s) in config", len(errs))
+	}
+	cfg, err := config.Load(projectRoot)
+	if err != nil {
+		return nil, nil, fmt.Errorf("loading config: %w", err)
+	}
+	return cfg, warnings, nil
+}
+
+// checkStrict 在 strict: true 时把警告变成错误.
+func checkStrict(cfg *config.Config, warnings []string) error {
+	if cfg.Strict && len(warnings) > 0 {
+		return fmt.Errorf("%d warning(s) treated as errors (strict: true)", len(warnings))
+	}
+	return nil
+}
+
+func printWarnings(warnings []string) {
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", w)
+	}
+}
+
+// lint 检查 blog.yaml 和 data/authors.yaml 中的未知键和类型错误.
+func lint(projectRoot string) (warnings, errs []string) {
+	files := []struct {
+		name  string
+		check func([]byte) []validate.Issue
+	}{
+		{"blog.yaml", validate.YAML[config.Config]},
+		{"data/authors.yaml", validate.YAML[map[string]config.AuthorConfig]},
+	}
+	for _, f := range files {
+		data, err := os.ReadFile(filepath.Join(projectRoot, filepath.FromSlash(f.name)))
+		if err != nil {
+			continue
+		}
+		for _, issue := range f.check(data) {
+			if issue.Error {
+				errs = append(errs, f.name+": "+issue.String())
+			} else {
+				warnings = append(warnings, f.name+": "+issue.String())
+			}
+		}
+	}
+	return warnings, errs
+}
+
+func checkL10n(cfg *config.Config) []string {
+	var warnings []string
+	for _, lc := range cfg.Localized() {
+		if missing := l10n.Missing(lc.Lang, lc.L10n); len(missing) > 0 {
+			warnings = append(warnings, fmt.Sprintf("blog.yaml: l10n keys missing for language %q, falling back to English: %s",
+				lc.Lang, strings.Join(missing, ", ")))
+		}
+	}
+	if unknown := l10n.Unknown(cfg.L10n); len(unknown) > 0 {
+		warnings = append(warnings, "blog.yaml: unknown l10n keys: "+strings.Join(unknown, ", "))
+	}
+	for _, l := range cfg.Languages {
+		if unknown := l10n.Unknown(l.L10n); len(unknown) > 0 {
+			warnings = append(warnings, fmt.Sprintf("blog.yaml: unknown l10n keys for language %q: %s", l.Code, strings.Join(unknown, ", ")))
+		}
+	}
+	return warnings
+}
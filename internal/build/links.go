@@ -0,0 +1,80 @@
+package build
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/zhhc99/bgen/internal/config"
+	"github.com/zhhc99/bgen/internal/linkcheck"
+)
+
+// CheckLinks 用于 bgen check --links.
+func CheckLinks(projectRoot string) error {
+	tmpDir, err := os.MkdirTemp("", "bgen-links-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	cfg, res, err := check(projectRoot, tmpDir)
+	if err != nil {
+		return err
+	}
+	return checkLinks(cfg, tmpDir, res.Pages)
+}
+
+// checkLinks 检查 outDir 中的内部链接, 按页面打印坏链接.
+func checkLinks(cfg *config.Config, outDir string, pages map[string]string) error {
+	broken, err := linkcheck.Check(outDir, cfg.BaseURL, cfg.BasePath)
+	if err != nil {
+		return fmt.Errorf("checking links: %w", err)
+	}
+	if len(broken) == 0 {
+		fmt.Println("links ok")
+		return nil
+	}
+	count := 0
+	for i, b := range broken {
+		if i == 0 || b.Page != broken[i-1].Page {
+			count++
+			if source, ok := pages[b.Page]; ok {
+				fmt.Fprintf(os.Stderr, "%s (%s):\n", b.Page, source)
+			} else {
+				fmt.Fprintf(os.Stderr, "%s:\n", b.Page)
+			}
+		}
+		fmt.Fprintf(os.Stderr, "  %s: %s\n", b.Link, b.Reason)
+	}
+	return fmt.Errorf("%d broken link(s) in %d page(s)", len(broken), count)
+}
+
+// copyOutput 把 src 中的文件复制到 dest.
+func copyOutput(src, dest string) error {
+	return filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel(src, p)
+		target := filepath.Join(dest, rel)
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		in, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		out, err := os.Create(target)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, in); err != nil {
+			out.Close()
+			return err
+		}
+		return out.Close()
+	})
+}
@@ -1,11 +1,17 @@
 package config
 
 import (
+	"cmp"
 	"fmt"
+	"maps"
 	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -19,28 +25,126 @@ type FrontMatterDefaults struct {
 	Author string `yaml:"author"`
 }
 
+type AuthorConfig struct {
+	Name   string            `yaml:"name"`
+	Bio    string            `yaml:"bio"`
+	Avatar string            `yaml:"avatar"` // URL 或站内路径
+	Email  string            `yaml:"email"`  // 仅用于 feed 的 <author>
+	Links  map[string]string `yaml:"links"`  // 显示名 -> URL
+}
+
+// MenuItemConfig 是导航菜单的一项, page, section 和 url 三者选一.
+type MenuItemConfig struct {
+	Label    string           `yaml:"label"`   // 默认为页面标题或分区名
+	Page     string           `yaml:"page"`    // 页面 slug, 如 about, docs/install
+	Section  string           `yaml:"section"` // search, tags, archives 或内容分区名
+	URL      string           `yaml:"url"`     // 外部链接
+	Weight   int              `yaml:"weight"`  // 小的在前, 相同时保持书写顺序
+	Children []MenuItemConfig `yaml:"children"`
+}
+
+// LanguageConfig 中非空的字段覆盖顶层配置, 其中 nav 和 l10n 按键合并.
+type LanguageConfig struct {
+	Code   string            `yaml:"code"`
+	Title  string            `yaml:"title"`
+	Hero   HeroConfig        `yaml:"hero"`
+	Nav    map[string]string `yaml:"nav"`
+	L10n   map[string]string `yaml:"l10n"`
+	Menu   []MenuItemConfig  `yaml:"menu"`
+	Params map[string]any    `yaml:"params"`
+}
+
+// SectionConfig 定义 content/ 下除 posts 外的一个内容分区, 如 notes, projects.
+type SectionConfig struct {
+	Name  string `yaml:"name"`  // content/ 下的目录名, 也是 URL 前缀
+	Title string `yaml:"title"` // 列表页标题, 默认为 name
+}
+
 type Config struct {
-	Title               string              `yaml:"title"`
-	BaseURL             string              `yaml:"base_url"`
-	BasePath            string              `yaml:"-"` // derived from BaseURL, e.g. "/~john"
-	Hero                HeroConfig          `yaml:"hero"`
-	Nav                 map[string]string   `yaml:"nav"`
-	L10n                map[string]string   `yaml:"l10n"`
-	FrontMatterDefaults FrontMatterDefaults `yaml:"front-matter-defaults"`
+	Title               string                  `yaml:"title"`
+	BaseURL             string                  `yaml:"base_url"`
+	BasePath            string                  `yaml:"-"` // derived from BaseURL, e.g. "/~john"
+	RootURL             string                  `yaml:"-"` // 多语言时 BaseURL/BasePath 带语言前缀, Root* 不带
+	RootPath            string                  `yaml:"-"`
+	Lang                string                  `yaml:"-"` // 当前语言
+	LangPath            string                  `yaml:"-"` // 非默认语言为 "/en" 这样的前缀, 默认语言为空
+	Hero                HeroConfig              `yaml:"hero"`
+	Language            string                  `yaml:"language"` // 单语言站点的语言, 默认 zh
+	Timezone            string                  `yaml:"timezone"` // IANA 时区名, 用于没有写时区的日期, 默认 UTC
+	Location            *time.Location          `yaml:"-"`
+	Nav                 map[string]string       `yaml:"nav"`
+	L10n                map[string]string       `yaml:"l10n"`
+	FrontMatterDefaults FrontMatterDefaults     `yaml:"front-matter-defaults"`
+	RelatedPosts        int                     `yaml:"related_posts"`    // 文章页相关文章数量, 0 表示关闭
+	TagAliases          map[string]string       `yaml:"tag_aliases"`      // 别名 -> 规范 tag, 忽略大小写
+	Authors             map[string]AuthorConfig `yaml:"authors"`          // 作者 id -> 资料, 也可以写在 data/authors.yaml
+	Languages           []LanguageConfig        `yaml:"languages"`        // 第一个是默认语言, 位于站点根目录
+	SourceURL           string                  `yaml:"source_url"`       // 文章源文件链接, {path} 替换为仓库中的路径
+	Sections            []SectionConfig         `yaml:"sections"`         // posts 总是存在, 可以在这里设置它的 title
+	Menu                []MenuItemConfig        `yaml:"menu"`             // 未设置时由 nav, 分区和顶层页面生成
+	Params              map[string]any          `yaml:"params"`           // 主题自定义参数, 模板中为 .Site.Params
+	Strict              bool                    `yaml:"strict"`           // build 和 check 把警告当作错误, 用于 CI
+	StaticOverrides     bool                    `yaml:"static_overrides"` // static/ 中的文件可以覆盖生成的文件
+	BundleExclude       []string                `yaml:"bundle_exclude"`   // 不复制的 bundle 文件, 如 *.psd, drafts
+	CheckLinks          bool                    `yaml:"check_links"`      // build 后检查生成的页面中的内部链接
 }
 
+const (
+	defaultRelatedPosts = 5
+	defaultLang         = "zh"
+	DefaultSection      = "posts"
+)
+
+var (
+	reLangCode    = regexp.MustCompile(`^[A-Za-z0-9-]+$`)
+	reSectionName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+	// 这些 URL 前缀已被其他页面使用
+	reservedSections = []string{"tags", "series", "authors", "search", "archives"}
+)
+
 func Load(projectRoot string) (*Config, error) {
-	path := filepath.Join(projectRoot, "blog.yaml")
-	data, err := os.ReadFile(path)
+	data, err := os.ReadFile(filepath.Join(projectRoot, "blog.yaml"))
 	if err != nil {
 		return nil, fmt.Errorf("reading blog.yaml: %w", err)
 	}
 
-	var cfg Config
+	cfg := Config{RelatedPosts: defaultRelatedPosts}
 	if err := yaml.Unmarshal(data, &cfg); err != nil {
 		return nil, fmt.Errorf("parsing blog.yaml: %w", err)
 	}
 
+	if err := loadAuthors(projectRoot, &cfg); err != nil {
+		return nil, err
+	}
+	cfg.Location = time.UTC
+	if cfg.Timezone != "" {
+		loc, err := time.LoadLocation(cfg.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("blog.yaml: invalid timezone %q: %w", cfg.Timezone, err)
+		}
+		cfg.Location = loc
+	}
+
+	seen := make(map[string]bool)
+	for _, l := range cfg.Languages {
+		if !reLangCode.MatchString(l.Code) {
+			return nil, fmt.Errorf("blog.yaml: invalid language code %q", l.Code)
+		}
+		if seen[l.Code] {
+			return nil, fmt.Errorf("blog.yaml: duplicate language %q", l.Code)
+		}
+		seen[l.Code] = true
+	}
+
+	if err := checkSections(&cfg); err != nil {
+		return nil, err
+	}
+	for _, pattern := range cfg.BundleExclude {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("blog.yaml: invalid bundle_exclude pattern %q", pattern)
+		}
+	}
+
 	if cfg.BaseURL != "" {
 		cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
 		if u, err := url.Parse(cfg.BaseURL); err == nil {
@@ -50,3 +154,107 @@ func Load(projectRoot string) (*Config, error) {
 
 	return &cfg, nil
 }
+
+// checkSections 检查分区名, 并保证 posts 是第一个分区.
+func checkSections(cfg *Config) error {
+	seen := make(map[string]bool)
+	for _, sec := range cfg.Sections {
+		if !reSectionName.MatchString(sec.Name) {
+			return fmt.Errorf("blog.yaml: invalid section name %q", sec.Name)
+		}
+		if slices.Contains(reservedSections, sec.Name) {
+			return fmt.Errorf("blog.yaml: section name %q is reserved", sec.Name)
+		}
+		if slices.ContainsFunc(cfg.Languages, func(l LanguageConfig) bool { return strings.EqualFold(l.Code, sec.Name) }) {
+			return fmt.Errorf("blog.yaml: section name %q is a language code", sec.Name)
+		}
+		if seen[sec.Name] {
+			return fmt.Errorf("blog.yaml: duplicate section %q", sec.Name)
+		}
+		seen[sec.Name] = true
+	}
+	i := slices.IndexFunc(cfg.Sections, func(sec SectionConfig) bool { return sec.Name == DefaultSection })
+	switch {
+	case i == -1:
+		cfg.Sections = append([]SectionConfig{{Name: DefaultSection}}, cfg.Sections...)
+	case i > 0:
+		posts := cfg.Sections[i]
+		cfg.Sections = append([]SectionConfig{posts}, slices.Delete(slices.Clone(cfg.Sections), i, i+1)...)
+	}
+	return nil
+}
+
+// loadAuthors 合并 data/authors.yaml 中的作者资料, blog.yaml 中的同名条目优先.
+func loadAuthors(projectRoot string, cfg *Config) error {
+	data, err := os.ReadFile(filepath.Join(projectRoot, "data", "authors.yaml"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("reading data/authors.yaml: %w", err)
+	}
+	var authors map[string]AuthorConfig
+	if err := yaml.Unmarshal(data, &authors); err != nil {
+		return fmt.Errorf("parsing data/authors.yaml: %w", err)
+	}
+	if cfg.Authors == nil {
+		cfg.Authors = make(map[string]AuthorConfig)
+	}
+	for id, a := range authors {
+		if _, ok := cfg.Authors[id]; !ok {
+			cfg.Authors[id] = a
+		}
+	}
+	return nil
+}
+
+// Localized 为每种语言返回一份配置. 没有配置 languages 时只返回一份.
+// 需要在 BasePath 等字段最终确定后调用.
+func (c *Config) Localized() []*Config {
+	if len(c.Languages) == 0 {
+		lc := *c
+		lc.Lang, lc.RootURL, lc.RootPath = cmp.Or(c.Language, defaultLang), c.BaseURL, c.BasePath
+		return []*Config{&lc}
+	}
+	configs := make([]*Config, len(c.Languages))
+	for i, l := range c.Languages {
+		lc := *c
+		lc.Lang, lc.RootURL, lc.RootPath = l.Code, c.BaseURL, c.BasePath
+		if i > 0 {
+			lc.LangPath = "/" + l.Code
+			lc.BasePath += lc.LangPath
+			if lc.BaseURL != "" {
+				lc.BaseURL += lc.LangPath
+			}
+		}
+		if l.Title != "" {
+			lc.Title = l.Title
+		}
+		if l.Hero.Header != "" || l.Hero.Content != "" {
+			lc.Hero = l.Hero
+		}
+		if len(l.Menu) > 0 {
+			lc.Menu = l.Menu
+		}
+		lc.Nav = mergeStrings(c.Nav, l.Nav)
+		lc.L10n = mergeStrings(c.L10n, l.L10n)
+		if len(l.Params) > 0 {
+			lc.Params = maps.Clone(c.Params)
+			if lc.Params == nil {
+				lc.Params = make(map[string]any)
+			}
+			maps.Copy(lc.Params, l.Params)
+		}
+		configs[i] = &lc
+	}
+	return configs
+}
+
+func mergeStrings(base, override map[string]string) map[string]string {
+	merged := maps.Clone(base)
+	if merged == nil {
+		merged = make(map[string]string)
+	}
+	maps.Copy(merged, override)
+	return merged
+}
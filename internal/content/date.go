@@ -0,0 +1,105 @@
+package content
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Time 是 front matter 中的时间. 没有写时区的值 (如 2024-01-15 21:30) 是 Floating 的,
+// 暂时以 UTC 保存, 由 Resolve 按站点时区解释.
+type Time struct {
+	time.Time
+	Floating bool
+}
+
+var (
+	zonedLayouts = []string{
+		time.RFC3339,
+		"2006-1-2T15:4:5Z07:00",
+		"2006-1-2 15:4:5Z07:00",
+		"2006-1-2 15:4:5 Z07:00",
+		"2006-1-2T15:4Z07:00",
+		"2006-1-2 15:4Z07:00",
+		"2006-1-2 15:4 Z07:00",
+	}
+	floatingLayouts = []string{
+		"2006-1-2",
+		"2006-1-2T15:4:5",
+		"2006-1-2 15:4:5",
+		"2006-1-2T15:4",
+		"2006-1-2 15:4",
+	}
+)
+
+func (t *Time) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.ScalarNode {
+		return fmt.Errorf("line %d: expected a date, got %s", node.Line, kindName(node.Kind))
+	}
+	if node.Value == "" || node.Tag == "!!null" {
+		*t = Time{}
+		return nil
+	}
+	for _, layout := range zonedLayouts {
+		if v, err := time.Parse(layout, node.Value); err == nil {
+			*t = Time{Time: v}
+			return nil
+		}
+	}
+	for _, layout := range floatingLayouts {
+		if v, err := time.Parse(layout, node.Value); err == nil {
+			*t = Time{Time: v, Floating: true}
+			return nil
+		}
+	}
+	return fmt.Errorf("line %d: cannot parse %q as a date, use 2006-01-02 or 2006-01-02 15:04", node.Line, node.Value)
+}
+
+// Resolve 返回 loc 时区下的时间. Floating 的值被解释为 loc 中的本地时间.
+func (t Time) Resolve(loc *time.Location) time.Time {
+	if t.IsZero() {
+		return time.Time{}
+	}
+	if t.Floating {
+		y, m, d := t.Date()
+		return time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+	}
+	return t.In(loc)
+}
+
+func kindName(k yaml.Kind) string {
+	switch k {
+	case yaml.SequenceNode:
+		return "a list"
+	case yaml.MappingNode:
+		return "a mapping"
+	default:
+		return "a non-scalar value"
+	}
+}
+
+var reDatePrefix = regexp.MustCompile(`^(\d{4}-\d{2}(?:-\d{2})?)-(.+)$`)
+
+// SplitDatePrefix 拆分 2024-01-15-hello 或 2024-01-hello 这样的文件名,
+// 返回日期前缀, 解析出的日期 (Floating) 和剩余部分. 没有日期前缀时 prefix 为空, rest 为 name.
+func SplitDatePrefix(name string) (prefix string, date Time, rest string) {
+	m := reDatePrefix.FindStringSubmatch(name)
+	if m == nil {
+		return "", Time{}, name
+	}
+	layout := "2006-01-02"
+	if len(m[1]) == len("2006-01") {
+		layout = "2006-01"
+		// 2024-01-15 整个是日期, 不能拆成 2024-01 和 15
+		if _, err := time.Parse("2006-01-02", name); err == nil {
+			return "", Time{}, name
+		}
+	}
+	t, err := time.Parse(layout, m[1])
+	if err != nil {
+		return "", Time{}, name
+	}
+	return m[1], Time{Time: t, Floating: true}, m[2]
+}
@@ -0,0 +1,88 @@
+package content_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zhhc99/bgen/internal/content"
+)
+
+func TestDate(t *testing.T) {
+	shanghai := time.FixedZone("UTC+8", 8*3600)
+
+	cases := []struct {
+		name  string
+		value string
+		want  time.Time
+	}{
+		{"只有日期", "2024-01-15", time.Date(2024, 1, 15, 0, 0, 0, 0, shanghai)},
+		{"日期和时间", "2024-01-15 21:30", time.Date(2024, 1, 15, 21, 30, 0, 0, shanghai)},
+		{"带秒", "2024-01-15T21:30:05", time.Date(2024, 1, 15, 21, 30, 5, 0, shanghai)},
+		{"单个数字", "2024-1-5", time.Date(2024, 1, 5, 0, 0, 0, 0, shanghai)},
+		{"带时区的值不受站点时区影响", "2024-01-15T21:30:00+09:00", time.Date(2024, 1, 15, 21, 30, 0, 0, time.FixedZone("", 9*3600))},
+		{"UTC", "2024-01-15T13:30:00Z", time.Date(2024, 1, 15, 21, 30, 0, 0, shanghai)},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			pf, err := content.Parse([]byte("---\ndate: " + c.value + "\n---\n"))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got := pf.Front.Date.Resolve(shanghai)
+			if !got.Equal(c.want) {
+				t.Errorf("got %v, want %v", got, c.want)
+			}
+			if got.Location() != shanghai {
+				t.Errorf("location: got %v, want %v", got.Location(), shanghai)
+			}
+		})
+	}
+
+	t.Run("未设置", func(t *testing.T) {
+		pf, err := content.Parse([]byte("---\ntitle: x\n---\n"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !pf.Front.Updated.Resolve(shanghai).IsZero() {
+			t.Error("missing updated should resolve to zero time")
+		}
+	})
+
+	t.Run("无法解析", func(t *testing.T) {
+		_, err := content.Parse([]byte("---\ndate: yesterday\n---\n"))
+		if err == nil {
+			t.Fatal("expected error for invalid date")
+		}
+	})
+}
+
+func TestSplitDatePrefix(t *testing.T) {
+	cases := []struct {
+		name       string
+		wantPrefix string
+		wantDate   time.Time
+		wantRest   string
+	}{
+		{"2024-01-15-hello", "2024-01-15", time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), "hello"},
+		{"2024-01-hello", "2024-01", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), "hello"},
+		{"2024-01-15-2024-recap", "2024-01-15", time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), "2024-recap"},
+		{"hello", "", time.Time{}, "hello"},
+		{"2024-13-hello", "", time.Time{}, "2024-13-hello"},
+		{"2024-01-15", "", time.Time{}, "2024-01-15"},
+		{"2024-01-", "", time.Time{}, "2024-01-"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			prefix, date, rest := content.SplitDatePrefix(c.name)
+			if prefix != c.wantPrefix || rest != c.wantRest {
+				t.Errorf("got (%q, %q), want (%q, %q)", prefix, rest, c.wantPrefix, c.wantRest)
+			}
+			if !date.Time.Equal(c.wantDate) {
+				t.Errorf("date: got %v, want %v", date.Time, c.wantDate)
+			}
+			if prefix != "" && !date.Floating {
+				t.Error("date from file name should be floating")
+			}
+		})
+	}
+}
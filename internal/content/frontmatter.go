@@ -2,43 +2,292 @@ package content
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
+	"unicode"
 
+	"github.com/BurntSushi/toml"
+	"github.com/zhhc99/bgen/internal/validate"
 	"gopkg.in/yaml.v3"
 )
 
 type FrontMatter struct {
-	Title   string    `yaml:"title"`
-	Date    time.Time `yaml:"date"`
-	Tags    []string  `yaml:"tags"`
-	Slug    string    `yaml:"slug"`
-	Author  string    `yaml:"author"`
-	Summary string    `yaml:"summary"`
-	Ignore  bool      `yaml:"ignore"`
+	Title          string   `yaml:"title"`
+	Date           Time     `yaml:"date"`
+	Updated        Time     `yaml:"updated"`
+	Tags           []string `yaml:"tags"`
+	Slug           string   `yaml:"slug"`
+	Author         string   `yaml:"author"`
+	Authors        []string `yaml:"authors"`
+	Summary        string   `yaml:"summary"`
+	Description    string   `yaml:"description"`
+	Ignore         bool     `yaml:"ignore"`
+	Unlisted       bool     `yaml:"unlisted"`
+	Series         string   `yaml:"series"`
+	SeriesOrder    int      `yaml:"series_order"`
+	History        bool     `yaml:"history"` // 在文章页显示 git 修订历史
+	TOC            *bool    `yaml:"toc"`     // false 时不生成目录, 未设置时为 nil
+	Menu           *bool    `yaml:"menu"`    // 页面写 false 时不出现在默认菜单中
+	Weight         int      `yaml:"weight"`  // 页面在默认菜单和 Children 中的顺序, 小的在前
+	Layout         string   `yaml:"layout"`  // 页面使用 layouts/<layout>.html 代替 page.html
+	Lang           string   `yaml:"lang"`
+	TranslationKey string   `yaml:"translation_key"` // 默认为去掉语言后缀的文件名
+
+	Params map[string]any `yaml:",inline"` // 其他所有键, 供主题使用
 }
 
 type ParsedFile struct {
-	Front FrontMatter
-	Body  []byte
+	Front    FrontMatter
+	Body     []byte
+	BodyLine int      // 正文第一行在文件中的行号, 用于把 pandoc 报告的行号换算回文件
+	Warnings []string // front matter 中疑似拼写错误的键, 带有文件中的行号
+}
+
+// Source 是拆分后的 markdown 文件.
+type Source struct {
+	Format    string // FormatYAML, FormatTOML 或 FormatJSON
+	Front     []byte
+	Body      []byte
+	FrontLine int
+	BodyLine  int
 }
 
+// front matter 的格式由文件开头的分隔符决定.
+const (
+	FormatYAML = "yaml" // --- 包围
+	FormatTOML = "toml" // +++ 包围, 与 Hugo 相同
+	FormatJSON = "json" // 以 { 开头的 JSON 对象
+)
+
+var bom = []byte("\xef\xbb\xbf")
+
+// Parse 解析 markdown 文件的 front matter 和正文.
 func Parse(data []byte) (*ParsedFile, error) {
-	data = bytes.TrimSpace(data)
-	if !bytes.HasPrefix(data, []byte("---\n")) {
-		return nil, fmt.Errorf("missing front matter: file must start with ---")
+	src, err := SplitFrontMatter(data)
+	if err != nil {
+		return nil, err
 	}
-	rest := data[4:]
-	end := bytes.Index(rest, []byte("\n---"))
-	if end == -1 {
+	front, offset := src.Front, src.FrontLine-1
+	lineOf := func(line int) int { return line + offset }
+	if src.Format == FormatTOML {
+		if front, err = tomlToYAML(src.Front); err != nil {
+			return nil, fmt.Errorf("front matter: %s", shiftLines(err.Error(), offset))
+		}
+		lineOf = tomlLineOf(src.Front, front, offset)
+		offset = -1
+	}
+
+	pf := &ParsedFile{Body: src.Body, BodyLine: src.BodyLine}
+	var errs []string
+	for _, issue := range validate.YAML[FrontMatter](front) {
+		if issue.Line > 0 {
+			issue.Line = lineOf(issue.Line)
+		}
+		if issue.Error {
+			errs = append(errs, issue.String())
+		} else {
+			pf.Warnings = append(pf.Warnings, issue.String())
+		}
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("front matter: %s", strings.Join(errs, "; "))
+	}
+	if err := yaml.Unmarshal(front, &pf.Front); err != nil {
+		return nil, yamlError(err, offset)
+	}
+	return pf, nil
+}
+
+// SplitFrontMatter 拆分出 front matter 和正文.
+func SplitFrontMatter(data []byte) (*Source, error) {
+	data = bytes.ReplaceAll(bytes.TrimPrefix(data, bom), []byte("\r\n"), []byte("\n"))
+	trimmed := bytes.TrimSpace(data)
+	leading := len(data) - len(bytes.TrimLeftFunc(data, unicode.IsSpace))
+	startLine := 1 + bytes.Count(data[:leading], []byte("\n"))
+
+	src := &Source{FrontLine: startLine + 1}
+	var after []byte
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("---\n")):
+		src.Format = FormatYAML
+		src.Front, after = splitDelimited(trimmed[4:], "---")
+	case bytes.HasPrefix(trimmed, []byte("+++\n")):
+		src.Format = FormatTOML
+		src.Front, after = splitDelimited(trimmed[4:], "+++")
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		src.Format = FormatJSON
+		src.FrontLine = startLine
+		dec := json.NewDecoder(bytes.NewReader(trimmed))
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			var se *json.SyntaxError
+			if errors.As(err, &se) {
+				line := startLine + bytes.Count(trimmed[:se.Offset], []byte("\n"))
+				return nil, fmt.Errorf("front matter: line %d: %w", line, err)
+			}
+			return nil, fmt.Errorf("front matter: %w", err)
+		}
+		end := int(dec.InputOffset())
+		src.Front, after = trimmed[:end], trimmed[end:]
+	default:
+		return nil, fmt.Errorf("missing front matter: file must start with ---, +++ or {")
+	}
+	if after == nil {
 		return nil, fmt.Errorf("front matter not closed")
 	}
-	yamlPart := rest[:end]
-	body := bytes.TrimSpace(rest[end+4:])
 
-	var fm FrontMatter
-	if err := yaml.Unmarshal(yamlPart, &fm); err != nil {
-		return nil, fmt.Errorf("parsing front matter yaml: %w", err)
+	afterLeading := len(after) - len(bytes.TrimLeftFunc(after, unicode.IsSpace))
+	src.Body = bytes.TrimSpace(after)
+	src.BodyLine = src.FrontLine + bytes.Count(src.Front, []byte("\n")) + bytes.Count(after[:afterLeading], []byte("\n"))
+	if src.Format != FormatJSON && len(src.Front) > 0 {
+		src.BodyLine++ // 结束分隔符独占一行
+	}
+	return src, nil
+}
+
+// splitDelimited 在 rest 中找到以 delim 开头的结束行.
+func splitDelimited(rest []byte, delim string) (front, after []byte) {
+	if bytes.HasPrefix(rest, []byte(delim)) {
+		return rest[:0], rest[len(delim):]
+	}
+	end := bytes.Index(rest, []byte("\n"+delim))
+	if end == -1 {
+		return nil, nil
+	}
+	return rest[:end], rest[end+1+len(delim):]
+}
+
+// tomlToYAML 把 TOML front matter 转换为等价的 yaml.
+func tomlToYAML(front []byte) ([]byte, error) {
+	var m map[string]any
+	if _, err := toml.Decode(string(front), &m); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(tomlValue(m))
+}
+
+// tomlLineOf 按键路径把转换后的 yaml 中的行号对应到 TOML 文件中的行号, 找不到时为 0.
+func tomlLineOf(tomlFront, yamlFront []byte, offset int) func(int) int {
+	tomlLines := make(map[string]int) // 键路径 -> 行号
+	table := ""
+	for i, line := range strings.Split(string(tomlFront), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") {
+			table = strings.Trim(line[:strings.LastIndex(line, "]")+1], "[] ")
+			if _, ok := tomlLines[table]; !ok {
+				tomlLines[table] = i + 1
+			}
+			continue
+		}
+		key, _, ok := strings.Cut(line, "=")
+		if !ok || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key = strings.ReplaceAll(strings.ReplaceAll(strings.TrimSpace(key), `"`, ""), " ", "")
+		if table != "" {
+			key = table + "." + key
+		}
+		if _, ok := tomlLines[key]; !ok {
+			tomlLines[key] = i + 1
+		}
+	}
+
+	yamlKeys := make(map[int]string) // 行号 -> 键路径
+	var walk func(n *yaml.Node, path string)
+	walk = func(n *yaml.Node, path string) {
+		if n.Kind != yaml.MappingNode {
+			for _, c := range n.Content {
+				walk(c, path)
+			}
+			return
+		}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key := strings.TrimPrefix(path+"."+n.Content[i].Value, ".")
+			yamlKeys[n.Content[i].Line] = key
+			walk(n.Content[i+1], key)
+		}
+	}
+	var doc yaml.Node
+	if yaml.Unmarshal(yamlFront, &doc) == nil {
+		walk(&doc, "")
+	}
+
+	return func(line int) int {
+		for ; line > 0; line-- {
+			if path, ok := yamlKeys[line]; ok {
+				// 找不到时退回到上一级键, 如 [params] 表头
+				for ; path != ""; path = path[:max(strings.LastIndex(path, "."), 0)] {
+					if n, ok := tomlLines[path]; ok {
+						return n + offset
+					}
+				}
+				return 0
+			}
+		}
+		return 0
+	}
+}
+
+// tomlValue 把 TOML 的日期时间换成字符串.
+func tomlValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, x := range v {
+			v[k] = tomlValue(x)
+		}
+	case []map[string]any:
+		for _, x := range v {
+			tomlValue(x)
+		}
+	case []any:
+		for i, x := range v {
+			v[i] = tomlValue(x)
+		}
+	case time.Time:
+		switch v.Location().String() {
+		case "date-local":
+			return v.Format("2006-01-02")
+		case "datetime-local":
+			return v.Format("2006-01-02T15:04:05")
+		case "time-local":
+			return v.Format("15:04:05")
+		}
+		return v.Format(time.RFC3339)
+	}
+	return v
+}
+
+var (
+	reLine       = regexp.MustCompile(`line (\d+)`)
+	reLinePrefix = regexp.MustCompile(`^line \d+: `)
+)
+
+// yamlError 把 yaml 错误中的行号换算为文件中的行号.
+func yamlError(err error, offset int) error {
+	msgs := []string{strings.TrimPrefix(err.Error(), "yaml: ")}
+	var te *yaml.TypeError
+	if errors.As(err, &te) {
+		msgs = slices.Clone(te.Errors)
+	}
+	for i, msg := range msgs {
+		msgs[i] = shiftLines(msg, offset)
+	}
+	return fmt.Errorf("front matter: %s", strings.Join(msgs, "; "))
+}
+
+// shiftLines 把 msg 中的 "line N" 加上 offset.
+func shiftLines(msg string, offset int) string {
+	if offset < 0 {
+		return reLinePrefix.ReplaceAllString(msg, "")
 	}
-	return &ParsedFile{Front: fm, Body: body}, nil
+	return reLine.ReplaceAllStringFunc(msg, func(m string) string {
+		n, _ := strconv.Atoi(strings.TrimPrefix(m, "line "))
+		return fmt.Sprintf("line %d", n+offset)
+	})
 }
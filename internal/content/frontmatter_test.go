@@ -1,6 +1,7 @@
 package content_test
 
 import (
+	"strings"
 	"testing"
 	"time"
 
@@ -56,6 +57,24 @@ author: alice
 		}
 	})
 
+	t.Run("自定义字段", func(t *testing.T) {
+		input := []byte("---\ntitle: Custom\naccent: \"#d97757\"\nhide_cover: true\nlinks:\n  demo: https://example.com\n---\n")
+		pf, err := content.Parse(input)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		p := pf.Front.Params
+		if p["accent"] != "#d97757" || p["hide_cover"] != true {
+			t.Errorf("params: got %v", p)
+		}
+		if links, ok := p["links"].(map[string]any); !ok || links["demo"] != "https://example.com" {
+			t.Errorf("params.links: got %#v", p["links"])
+		}
+		if _, ok := p["title"]; ok {
+			t.Error("known keys should not be in params")
+		}
+	})
+
 	t.Run("缺少 front matter", func(t *testing.T) {
 		input := []byte("没有 front matter 的文件\n")
 		_, err := content.Parse(input)
@@ -82,4 +101,114 @@ author: alice
 			t.Errorf("expected empty body, got %q", pf.Body)
 		}
 	})
+	t.Run("行号相对于文件", func(t *testing.T) {
+		input := []byte("\n---\ntitle: Lines\ntag: go\n---\n\n\n正文\n")
+		pf, err := content.Parse(input)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if pf.BodyLine != 8 {
+			t.Errorf("body line: got %d, want 8", pf.BodyLine)
+		}
+		if len(pf.Warnings) != 1 || pf.Warnings[0] != `line 4: unknown key "tag", did you mean "tags"?` {
+			t.Errorf("warnings: got %q", pf.Warnings)
+		}
+
+		_, err = content.Parse([]byte("---\ntitle: Lines\nsummary: a: b\n---\n"))
+		if err == nil || !strings.Contains(err.Error(), "line 3:") {
+			t.Errorf("syntax error: got %v, want line 3", err)
+		}
+		_, err = content.Parse([]byte("---\ntitle: Lines\nweight: heavy\n---\n"))
+		if err == nil || !strings.Contains(err.Error(), `line 3: "weight": expected an integer`) {
+			t.Errorf("type error: got %v", err)
+		}
+	})
+	t.Run("TOML", func(t *testing.T) {
+		input := []byte("+++\ntitle = \"Hugo\"\ndate = 2024-01-15\nupdated = 2024-02-01T10:00:00+08:00\ntags = [\"go\", \"hugo\"]\naccent = \"teal\"\n\n[links]\ndemo = \"https://example.com\"\n+++\n\n正文\n")
+		pf, err := content.Parse(input)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		fm := pf.Front
+		if fm.Title != "Hugo" || len(fm.Tags) != 2 || fm.Tags[1] != "hugo" {
+			t.Errorf("front matter: got %+v", fm)
+		}
+		if !fm.Date.Floating || !fm.Date.Equal(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("date: got %v (floating %v)", fm.Date, fm.Date.Floating)
+		}
+		if fm.Updated.Floating || !fm.Updated.Equal(time.Date(2024, 2, 1, 2, 0, 0, 0, time.UTC)) {
+			t.Errorf("updated: got %v", fm.Updated)
+		}
+		if fm.Params["accent"] != "teal" || fm.Params["links"].(map[string]any)["demo"] != "https://example.com" {
+			t.Errorf("params: got %v", fm.Params)
+		}
+		if string(pf.Body) != "正文" || pf.BodyLine != 12 {
+			t.Errorf("body: got %q at line %d", pf.Body, pf.BodyLine)
+		}
+
+		pf, err = content.Parse([]byte("+++\ntitle = \"x\"\n\ntag = \"go\"\n\n[links]\ntitel = \"y\"\n+++\n"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(pf.Warnings) != 1 || pf.Warnings[0] != `line 4: unknown key "tag", did you mean "tags"?` {
+			t.Errorf("warnings: got %q", pf.Warnings)
+		}
+		_, err = content.Parse([]byte("+++\ntitle = \"x\"\ntags = \"go\"\n+++\n"))
+		if err == nil || !strings.Contains(err.Error(), `line 3: "tags": expected a list`) {
+			t.Errorf("type error: got %v", err)
+		}
+		_, err = content.Parse([]byte("+++\ntitle = \"x\"\ntags = [\n+++\n"))
+		if err == nil || !strings.Contains(err.Error(), "line 3") {
+			t.Errorf("syntax error: got %v, want line 3", err)
+		}
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		input := []byte("{\n  \"title\": \"Generated\",\n  \"date\": \"2024-01-15\",\n  \"tags\": [\"go\"],\n  \"tag\": \"x\",\n  \"score\": 3\n}\n\n正文 {不是 JSON}\n")
+		pf, err := content.Parse(input)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if pf.Front.Title != "Generated" || pf.Front.Date.Day() != 15 || len(pf.Front.Tags) != 1 {
+			t.Errorf("front matter: got %+v", pf.Front)
+		}
+		if pf.Front.Params["score"] != 3 {
+			t.Errorf("params: got %v", pf.Front.Params)
+		}
+		if string(pf.Body) != "正文 {不是 JSON}" || pf.BodyLine != 9 {
+			t.Errorf("body: got %q at line %d", pf.Body, pf.BodyLine)
+		}
+		if len(pf.Warnings) != 1 || !strings.HasPrefix(pf.Warnings[0], "line 5: ") {
+			t.Errorf("warnings: got %q", pf.Warnings)
+		}
+
+		_, err = content.Parse([]byte("{\n  \"title\": \"x\",\n  oops\n}\n"))
+		if err == nil || !strings.Contains(err.Error(), "line 3") {
+			t.Errorf("syntax error: got %v, want line 3", err)
+		}
+	})
+
+	t.Run("BOM 和 CRLF", func(t *testing.T) {
+		input := []byte("\xef\xbb\xbf---\r\ntitle: Windows\r\ntags: [a]\r\n---\r\n\r\n第一行\r\n第二行\r\n")
+		pf, err := content.Parse(input)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if pf.Front.Title != "Windows" || len(pf.Front.Tags) != 1 {
+			t.Errorf("front matter: got %+v", pf.Front)
+		}
+		if string(pf.Body) != "第一行\n第二行" || pf.BodyLine != 6 {
+			t.Errorf("body: got %q at line %d", pf.Body, pf.BodyLine)
+		}
+	})
+
+	t.Run("空 front matter", func(t *testing.T) {
+		pf, err := content.Parse([]byte("---\n---\n正文\n"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if string(pf.Body) != "正文" || pf.BodyLine != 3 {
+			t.Errorf("body: got %q at line %d", pf.Body, pf.BodyLine)
+		}
+	})
 }
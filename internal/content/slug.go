@@ -0,0 +1,40 @@
+package content
+
+import (
+	"strings"
+	"unicode"
+)
+
+// 这些符号单独出现时有含义, 不能直接丢掉, 否则 C++ 和 C 会撞在一起.
+var slugWords = map[rune]string{
+	'+': "plus",
+	'#': "sharp",
+	'&': "and",
+	'@': "at",
+}
+
+// Slugify 把任意名字转换成可以安全用作单级 URL 路径的形式.
+// 字母 (包括中文等 Unicode 字母) 和数字保留并转为小写, 其余字符折叠为 "-".
+// 结果不含 "/", "." 等字符, 因此不会产生多级目录或越界路径.
+func Slugify(name string) string {
+	var words []string
+	var cur strings.Builder
+	flush := func() {
+		if cur.Len() > 0 {
+			words = append(words, cur.String())
+			cur.Reset()
+		}
+	}
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) {
+			cur.WriteRune(r)
+			continue
+		}
+		flush()
+		if w, ok := slugWords[r]; ok {
+			words = append(words, w)
+		}
+	}
+	flush()
+	return strings.Join(words, "-")
+}
@@ -0,0 +1,32 @@
+package content_test
+
+import (
+	"testing"
+
+	"github.com/zhhc99/bgen/internal/content"
+)
+
+func TestSlugify(t *testing.T) {
+	cases := []struct {
+		in, want string
+	}{
+		{"go", "go"},
+		{"Go", "go"},
+		{"machine learning", "machine-learning"},
+		{"C++", "c-plus-plus"},
+		{"C#", "c-sharp"},
+		{"a/b", "a-b"},
+		{"../x", "x"},
+		{"..", ""},
+		{"  spaced  out  ", "spaced-out"},
+		{"机器学习", "机器学习"},
+		{"Go 语言", "go-语言"},
+		{"Café", "café"},
+		{"v1.2", "v1-2"},
+	}
+	for _, c := range cases {
+		if got := content.Slugify(c.in); got != c.want {
+			t.Errorf("Slugify(%q): got %q, want %q", c.in, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+// Package data 读取项目 data/ 目录下的 yaml 和 json 文件, 供模板使用.
+package data
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Load 读取 dir 下所有 .yaml, .yml 和 .json 文件. 文件名 (不含扩展名) 是键,
+// 子目录成为嵌套的 map. dir 不存在时返回空 map.
+// 错误信息中的路径相对于 dir 的上级目录, 如 data/talks.yaml.
+func Load(dir string) (map[string]any, error) {
+	root := filepath.Dir(dir)
+	realDir, _ := filepath.EvalSymlinks(dir)
+	result := make(map[string]any)
+	sources := make(map[string]string) // 键路径 -> 定义它的文件
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == dir {
+				return nil
+			}
+			return err
+		}
+		if strings.HasPrefix(d.Name(), ".") && path != dir {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		ext := filepath.Ext(path)
+		if d.IsDir() || (ext != ".yaml" && ext != ".yml" && ext != ".json") {
+			return nil
+		}
+
+		name, _ := filepath.Rel(root, path)
+		name = filepath.ToSlash(name)
+		if real, err := filepath.EvalSymlinks(path); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		} else if r, err := filepath.Rel(realDir, real); err != nil || !filepath.IsLocal(r) {
+			return fmt.Errorf("%s: links to a file outside %s/", name, filepath.Base(dir))
+		}
+		value, err := parseFile(path, ext)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+
+		rel, _ := filepath.Rel(dir, path)
+		keys := strings.Split(strings.TrimSuffix(filepath.ToSlash(rel), ext), "/")
+		m := result
+		for i, k := range keys[:len(keys)-1] {
+			child, ok := m[k].(map[string]any)
+			if !ok {
+				if _, exists := m[k]; exists {
+					return fmt.Errorf("%s: key %q is already defined by %s", name, strings.Join(keys[:i+1], "."), sources[strings.Join(keys[:i+1], ".")])
+				}
+				child = make(map[string]any)
+				m[k] = child
+				sources[strings.Join(keys[:i+1], ".")] = filepath.ToSlash(filepath.Join(filepath.Base(dir), filepath.Join(keys[:i+1]...))) + "/"
+			}
+			m = child
+		}
+		last := keys[len(keys)-1]
+		keyPath := strings.Join(keys, ".")
+		if existing, ok := m[last]; ok {
+			// data/books.yaml 和 data/books/ 目录可以共存, 只要 books.yaml 是 map 且键不冲突
+			em, ok1 := existing.(map[string]any)
+			vm, ok2 := value.(map[string]any)
+			if !ok1 || !ok2 {
+				return fmt.Errorf("%s: key %q is already defined by %s", name, keyPath, sources[keyPath])
+			}
+			for k, v := range vm {
+				if _, dup := em[k]; dup {
+					return fmt.Errorf("%s: key %q is already defined by %s", name, keyPath+"."+k, sources[keyPath])
+				}
+				em[k] = v
+			}
+			return nil
+		}
+		m[last] = value
+		sources[keyPath] = name
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func parseFile(path, ext string) (any, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var v any
+	if ext == ".json" {
+		if err := json.Unmarshal(content, &v); err != nil {
+			var syntaxErr *json.SyntaxError
+			var typeErr *json.UnmarshalTypeError
+			switch {
+			case errors.As(err, &syntaxErr):
+				return nil, fmt.Errorf("line %d: %w", lineAt(content, syntaxErr.Offset), err)
+			case errors.As(err, &typeErr):
+				return nil, fmt.Errorf("line %d: %w", lineAt(content, typeErr.Offset), err)
+			}
+			return nil, err
+		}
+		return v, nil
+	}
+	if err := yaml.Unmarshal(content, &v); err != nil {
+		return nil, err // yaml 的错误信息已经带有行号
+	}
+	return v, nil
+}
+
+// lineAt 返回字节偏移 offset 所在的行号, 从 1 开始.
+func lineAt(content []byte, offset int64) int {
+	offset = min(offset, int64(len(content)))
+	return bytes.Count(content[:offset], []byte("\n")) + 1
+}
@@ -0,0 +1,83 @@
+package data_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zhhc99/bgen/internal/data"
+)
+
+func write(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "data")
+	write(t, filepath.Join(dir, "talks.yaml"), "- title: Go at Scale\n  year: 2023\n")
+	write(t, filepath.Join(dir, "blogroll.json"), `{"friends": ["alice", "bob"]}`)
+	write(t, filepath.Join(dir, "books/2024.yml"), "- Dune\n")
+	write(t, filepath.Join(dir, "books.yaml"), "favourite: SICP\n")
+	write(t, filepath.Join(dir, "notes.txt"), "ignored")
+
+	got, err := data.Load(dir)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	talks, ok := got["talks"].([]any)
+	if !ok || len(talks) != 1 || talks[0].(map[string]any)["title"] != "Go at Scale" {
+		t.Errorf("talks: got %#v", got["talks"])
+	}
+	friends := got["blogroll"].(map[string]any)["friends"].([]any)
+	if len(friends) != 2 || friends[1] != "bob" {
+		t.Errorf("blogroll: got %#v", got["blogroll"])
+	}
+	books := got["books"].(map[string]any)
+	if books["favourite"] != "SICP" {
+		t.Errorf("books.favourite: got %#v", books["favourite"])
+	}
+	if list, ok := books["2024"].([]any); !ok || list[0] != "Dune" {
+		t.Errorf("books.2024: got %#v", books["2024"])
+	}
+	if _, ok := got["notes"]; ok {
+		t.Error("non-data files should be ignored")
+	}
+}
+
+func TestLoad_Missing(t *testing.T) {
+	got, err := data.Load(filepath.Join(t.TempDir(), "data"))
+	if err != nil || len(got) != 0 {
+		t.Errorf("got (%v, %v), want empty map", got, err)
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	cases := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{"yaml 语法错误", map[string]string{"talks.yaml": "- title: a\n  year: [\n"}, "data/talks.yaml: yaml: line"},
+		{"json 语法错误", map[string]string{"links.json": "{\n  \"a\": 1,\n  \"b\": \n}"}, "data/links.json: line 4:"},
+		{"重复的键", map[string]string{"links.json": "[]", "links.yaml": "[]"}, `data/links.yaml: key "links" is already defined by data/links.json`},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "data")
+			for name, content := range c.files {
+				write(t, filepath.Join(dir, name), content)
+			}
+			_, err := data.Load(dir)
+			if err == nil || !strings.Contains(err.Error(), c.want) {
+				t.Errorf("got error %v, want it to contain %q", err, c.want)
+			}
+		})
+	}
+}
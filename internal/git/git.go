@@ -0,0 +1,73 @@
+// Package git 通过本地的 git 命令读取内容文件的提交历史.
+package git
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+type Commit struct {
+	Hash      string
+	ShortHash string
+	Date      time.Time // 作者时间
+	Subject   string
+}
+
+type History struct {
+	Prefix string              // 项目目录相对于仓库根目录的路径, 如 "blog/", 项目在根目录时为空
+	Files  map[string][]Commit // 相对于项目目录的 slash 路径 -> 提交, 新的在前
+}
+
+// Load 读取 dir 下 paths 的提交历史.
+// dir 不在 git 仓库中或没有安装 git 时返回 nil, 调用方应当把它当作没有历史.
+func Load(dir string, paths ...string) *History {
+	prefix, err := run(dir, "rev-parse", "--show-prefix")
+	if err != nil {
+		return nil
+	}
+	args := []string{
+		"-c", "core.quotepath=off",
+		"log", "--relative", "--name-only",
+		"--format=%x1e%H%x1f%h%x1f%aI%x1f%s",
+		"--",
+	}
+	out, err := run(dir, append(args, paths...)...)
+	if err != nil {
+		return nil
+	}
+	return &History{Prefix: strings.TrimSpace(prefix), Files: parseLog(out)}
+}
+
+func parseLog(out string) map[string][]Commit {
+	files := make(map[string][]Commit)
+	for _, record := range strings.Split(out, "\x1e") {
+		header, names, _ := strings.Cut(record, "\n")
+		fields := strings.Split(header, "\x1f")
+		if len(fields) != 4 {
+			continue
+		}
+		date, err := time.Parse(time.RFC3339, fields[2])
+		if err != nil {
+			continue
+		}
+		c := Commit{Hash: fields[0], ShortHash: fields[1], Date: date, Subject: fields[3]}
+		for _, name := range strings.Split(names, "\n") {
+			if name = strings.TrimSpace(name); name != "" {
+				files[name] = append(files[name], c)
+			}
+		}
+	}
+	return files
+}
+
+func run(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+	return stdout.String(), nil
+}
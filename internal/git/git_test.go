@@ -0,0 +1,76 @@
+package git_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/zhhc99/bgen/internal/git"
+)
+
+func TestLoad(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found in PATH")
+	}
+
+	repo := t.TempDir()
+	project := filepath.Join(repo, "blog")
+	gitRun := func(date string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", repo}, args...)...)
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=a", "GIT_AUTHOR_EMAIL=a@example.com", "GIT_AUTHOR_DATE="+date,
+			"GIT_COMMITTER_NAME=a", "GIT_COMMITTER_EMAIL=a@example.com", "GIT_COMMITTER_DATE="+date,
+		)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(rel, content string) {
+		t.Helper()
+		path := filepath.Join(project, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	gitRun("2024-01-01T10:00:00+08:00", "init", "-q")
+	write("content/posts/hello.md", "v1")
+	gitRun("2024-01-01T10:00:00+08:00", "add", "-A")
+	gitRun("2024-01-01T10:00:00+08:00", "commit", "-q", "-m", "add hello")
+	write("content/posts/hello.md", "v2")
+	write("content/about.md", "about")
+	gitRun("2024-02-01T10:00:00+08:00", "add", "-A")
+	gitRun("2024-02-01T10:00:00+08:00", "commit", "-q", "-m", "edit hello")
+
+	h := git.Load(project, "content")
+	if h == nil {
+		t.Fatal("expected history for a git repository")
+	}
+	if h.Prefix != "blog/" {
+		t.Errorf("prefix: got %q, want %q", h.Prefix, "blog/")
+	}
+	commits := h.Files["content/posts/hello.md"]
+	if len(commits) != 2 {
+		t.Fatalf("got %d commits, want 2", len(commits))
+	}
+	if commits[0].Subject != "edit hello" || commits[1].Subject != "add hello" {
+		t.Errorf("commits should be newest first, got %q, %q", commits[0].Subject, commits[1].Subject)
+	}
+	if commits[1].Date.Month() != 1 || len(commits[1].ShortHash) == 0 {
+		t.Errorf("unexpected commit %+v", commits[1])
+	}
+	if len(h.Files["content/about.md"]) != 1 {
+		t.Errorf("about.md: got %d commits, want 1", len(h.Files["content/about.md"]))
+	}
+}
+
+func TestLoad_NotARepository(t *testing.T) {
+	if h := git.Load(t.TempDir(), "content"); h != nil {
+		t.Errorf("expected nil history outside a repository, got %+v", h)
+	}
+}
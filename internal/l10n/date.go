@@ -0,0 +1,86 @@
+package l10n
+
+import (
+	"strings"
+	"time"
+)
+
+type dateNames struct {
+	months, shortMonths     [12]string
+	weekdays, shortWeekdays [7]string // 从星期日开始, 与 time.Weekday 一致
+}
+
+var dates = map[string]dateNames{
+	"zh": {
+		months:        [12]string{"一月", "二月", "三月", "四月", "五月", "六月", "七月", "八月", "九月", "十月", "十一月", "十二月"},
+		shortMonths:   [12]string{"1月", "2月", "3月", "4月", "5月", "6月", "7月", "8月", "9月", "10月", "11月", "12月"},
+		weekdays:      [7]string{"星期日", "星期一", "星期二", "星期三", "星期四", "星期五", "星期六"},
+		shortWeekdays: [7]string{"周日", "周一", "周二", "周三", "周四", "周五", "周六"},
+	},
+	"ja": {
+		months:        [12]string{"1月", "2月", "3月", "4月", "5月", "6月", "7月", "8月", "9月", "10月", "11月", "12月"},
+		shortMonths:   [12]string{"1月", "2月", "3月", "4月", "5月", "6月", "7月", "8月", "9月", "10月", "11月", "12月"},
+		weekdays:      [7]string{"日曜日", "月曜日", "火曜日", "水曜日", "木曜日", "金曜日", "土曜日"},
+		shortWeekdays: [7]string{"日", "月", "火", "水", "木", "金", "土"},
+	},
+	"de": {
+		months:        [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		shortMonths:   [12]string{"Jan.", "Feb.", "März", "Apr.", "Mai", "Juni", "Juli", "Aug.", "Sept.", "Okt.", "Nov.", "Dez."},
+		weekdays:      [7]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+		shortWeekdays: [7]string{"So.", "Mo.", "Di.", "Mi.", "Do.", "Fr.", "Sa."},
+	},
+	"fr": {
+		months:        [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		shortMonths:   [12]string{"janv.", "févr.", "mars", "avr.", "mai", "juin", "juil.", "août", "sept.", "oct.", "nov.", "déc."},
+		weekdays:      [7]string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+		shortWeekdays: [7]string{"dim.", "lun.", "mar.", "mer.", "jeu.", "ven.", "sam."},
+	},
+}
+
+// 按长度排列, 保证 "January" 先于 "Jan" 匹配.
+var nameTokens = []string{"January", "Monday", "Jan", "Mon"}
+
+// FormatDate 按 Go 的 layout 格式化日期, 并把月份和星期名替换成 lang 的写法.
+// 没有对应语言的名字表时与 t.Format 相同.
+func FormatDate(t time.Time, layout, lang string) string {
+	names, ok := dates[strings.ToLower(lang)]
+	if !ok {
+		base, _, _ := strings.Cut(strings.ToLower(lang), "-")
+		names, ok = dates[base]
+	}
+	if !ok {
+		return t.Format(layout)
+	}
+
+	var b strings.Builder
+	for layout != "" {
+		i, token := nextNameToken(layout)
+		if i == -1 {
+			b.WriteString(t.Format(layout))
+			break
+		}
+		b.WriteString(t.Format(layout[:i]))
+		switch token {
+		case "January":
+			b.WriteString(names.months[t.Month()-1])
+		case "Jan":
+			b.WriteString(names.shortMonths[t.Month()-1])
+		case "Monday":
+			b.WriteString(names.weekdays[t.Weekday()])
+		case "Mon":
+			b.WriteString(names.shortWeekdays[t.Weekday()])
+		}
+		layout = layout[i+len(token):]
+	}
+	return b.String()
+}
+
+func nextNameToken(layout string) (int, string) {
+	best, token := -1, ""
+	for _, tok := range nameTokens {
+		if i := strings.Index(layout, tok); i != -1 && (best == -1 || i < best) {
+			best, token = i, tok
+		}
+	}
+	return best, token
+}
@@ -0,0 +1,89 @@
+// Package l10n 提供默认模板使用的界面字符串和本地化的日期格式.
+package l10n
+
+import (
+	"embed"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// fallbackLang 的目录包含所有键, 其他语言缺少的键回退到它.
+const fallbackLang = "en"
+
+//go:embed catalogue/*.yaml
+var catalogueFS embed.FS
+
+var catalogues = mustLoadCatalogues()
+
+func mustLoadCatalogues() map[string]map[string]string {
+	entries, err := catalogueFS.ReadDir("catalogue")
+	if err != nil {
+		panic(err)
+	}
+	all := make(map[string]map[string]string, len(entries))
+	for _, e := range entries {
+		data, err := catalogueFS.ReadFile("catalogue/" + e.Name())
+		if err != nil {
+			panic(err)
+		}
+		var strs map[string]string
+		if err := yaml.Unmarshal(data, &strs); err != nil {
+			panic(fmt.Sprintf("l10n: %s: %v", e.Name(), err))
+		}
+		all[strings.TrimSuffix(e.Name(), ".yaml")] = strs
+	}
+	return all
+}
+
+// catalogue 返回 lang 的内置目录. "zh-CN" 这样的代码会退回到 "zh".
+func catalogue(lang string) map[string]string {
+	lang = strings.ToLower(lang)
+	if c, ok := catalogues[lang]; ok {
+		return c
+	}
+	base, _, _ := strings.Cut(lang, "-")
+	return catalogues[base]
+}
+
+// Keys 返回所有已知的键.
+func Keys() []string {
+	return slices.Sorted(maps.Keys(catalogues[fallbackLang]))
+}
+
+// Strings 返回 lang 的完整字符串表: 英文目录 < lang 的目录 < 用户的 l10n.
+func Strings(lang string, overrides map[string]string) map[string]string {
+	strs := maps.Clone(catalogues[fallbackLang])
+	maps.Copy(strs, catalogue(lang))
+	maps.Copy(strs, overrides)
+	return strs
+}
+
+// Missing 返回 lang 的内置目录和 overrides 都没有提供的键, 这些键会显示为英文.
+func Missing(lang string, overrides map[string]string) []string {
+	c := catalogue(lang)
+	var missing []string
+	for _, key := range Keys() {
+		if _, ok := c[key]; ok {
+			continue
+		}
+		if _, ok := overrides[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	return missing
+}
+
+// Unknown 返回 overrides 中不属于目录的键, 通常是拼写错误.
+func Unknown(overrides map[string]string) []string {
+	var unknown []string
+	for _, key := range slices.Sorted(maps.Keys(overrides)) {
+		if _, ok := catalogues[fallbackLang][key]; !ok {
+			unknown = append(unknown, key)
+		}
+	}
+	return unknown
+}
@@ -0,0 +1,73 @@
+package l10n_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/zhhc99/bgen/internal/l10n"
+)
+
+func TestCataloguesComplete(t *testing.T) {
+	for _, lang := range []string{"zh", "en", "ja", "de", "fr"} {
+		if missing := l10n.Missing(lang, nil); len(missing) > 0 {
+			t.Errorf("%s: missing keys %v", lang, missing)
+		}
+	}
+}
+
+func TestStrings(t *testing.T) {
+	t.Run("用户覆盖", func(t *testing.T) {
+		strs := l10n.Strings("zh", map[string]string{"toc": "本文目录"})
+		if strs["toc"] != "本文目录" {
+			t.Errorf("toc: got %q", strs["toc"])
+		}
+		if strs["related"] != "相关文章" {
+			t.Errorf("related: got %q", strs["related"])
+		}
+	})
+
+	t.Run("地区代码退回到语言", func(t *testing.T) {
+		if got := l10n.Strings("de-AT", nil)["toc"]; got != "Inhaltsverzeichnis" {
+			t.Errorf("toc: got %q", got)
+		}
+	})
+
+	t.Run("未知语言退回到英文", func(t *testing.T) {
+		if got := l10n.Strings("es", nil)["toc"]; got != "Table of Contents" {
+			t.Errorf("toc: got %q", got)
+		}
+		missing := l10n.Missing("es", map[string]string{"toc": "Índice"})
+		if slices.Contains(missing, "toc") || !slices.Contains(missing, "related") {
+			t.Errorf("missing: got %v", missing)
+		}
+	})
+
+	t.Run("未知的键", func(t *testing.T) {
+		got := l10n.Unknown(map[string]string{"toc": "x", "tco": "y"})
+		if !slices.Equal(got, []string{"tco"}) {
+			t.Errorf("unknown: got %v", got)
+		}
+	})
+}
+
+func TestFormatDate(t *testing.T) {
+	d := time.Date(2024, 3, 5, 21, 30, 0, 0, time.UTC) // 星期二
+	cases := []struct {
+		lang, layout, want string
+	}{
+		{"en", "January 2, 2006", "March 5, 2024"},
+		{"zh", "2006年1月2日", "2024年3月5日"},
+		{"zh", "January Monday", "三月 星期二"},
+		{"ja", "2006年1月2日 (Mon)", "2024年3月5日 (火)"},
+		{"de", "Monday, 2. January 2006", "Dienstag, 5. März 2024"},
+		{"fr", "Mon 2 Jan 2006 15:04", "mar. 5 mars 2024 21:30"},
+		{"fr-CA", "2 January", "5 mars"},
+		{"es", "2 January 2006", "5 March 2024"},
+	}
+	for _, c := range cases {
+		if got := l10n.FormatDate(d, c.layout, c.lang); got != c.want {
+			t.Errorf("FormatDate(%q, %q): got %q, want %q", c.layout, c.lang, got, c.want)
+		}
+	}
+}
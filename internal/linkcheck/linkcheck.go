@@ -0,0 +1,214 @@
+// Package linkcheck 检查生成的站点中的内部链接和锚点.
+package linkcheck
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/html"
+)
+
+// Broken 是一个指向不存在的文件或锚点的链接.
+type Broken struct {
+	Page   string // 含有链接的 HTML 文件, 相对于输出目录的 slash 路径
+	Link   string // href 或 src 的原始值
+	Reason string
+}
+
+// page 是一个 HTML 文件中的锚点和链接.
+type page struct {
+	ids   map[string]bool
+	links []string // 按出现顺序, 已去重
+}
+
+type checker struct {
+	baseURL  string // 站点根目录的完整 URL, 以它开头的绝对 URL 也算内部链接
+	basePath string // 站点根目录的路径, 如 /~john, 站点在域名根目录时为空
+	files    map[string]bool
+	pages    map[string]*page
+}
+
+// Check 检查 dir 中所有 HTML 文件的内部链接, 结果按页面排序.
+func Check(dir, baseURL, basePath string) ([]Broken, error) {
+	c := &checker{
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		basePath: strings.TrimRight(basePath, "/"),
+		files:    make(map[string]bool),
+		pages:    make(map[string]*page),
+	}
+	var htmlFiles []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, p)
+		rel = filepath.ToSlash(rel)
+		c.files[rel] = true
+		if strings.HasSuffix(rel, ".html") {
+			htmlFiles = append(htmlFiles, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := c.parseAll(dir, htmlFiles); err != nil {
+		return nil, err
+	}
+
+	sort.Strings(htmlFiles)
+	var broken []Broken
+	for _, rel := range htmlFiles {
+		for _, link := range c.pages[rel].links {
+			if reason := c.check(rel, link); reason != "" {
+				broken = append(broken, Broken{Page: rel, Link: link, Reason: reason})
+			}
+		}
+	}
+	return broken, nil
+}
+
+// parseAll 并行解析所有 HTML 文件.
+func (c *checker) parseAll(dir string, files []string) error {
+	results := make([]*page, len(files))
+	errs := make([]error, len(files))
+	sem := make(chan struct{}, runtime.GOMAXPROCS(0))
+	var wg sync.WaitGroup
+	for i, rel := range files {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(rel)))
+			if err == nil {
+				results[i], err = parse(bytes.NewReader(data))
+			}
+			if err != nil {
+				errs[i] = fmt.Errorf("%s: %w", rel, err)
+			}
+		}()
+	}
+	wg.Wait()
+	for i, rel := range files {
+		if errs[i] != nil {
+			return errs[i]
+		}
+		c.pages[rel] = results[i]
+	}
+	return nil
+}
+
+// parse 收集 HTML 中的 id 和链接.
+func parse(r io.Reader) (*page, error) {
+	p := &page{ids: make(map[string]bool)}
+	seen := make(map[string]bool)
+	addLink := func(link string) {
+		link = strings.TrimSpace(link)
+		if link != "" && !seen[link] {
+			seen[link] = true
+			p.links = append(p.links, link)
+		}
+	}
+	z := html.NewTokenizer(r)
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			if z.Err() == io.EOF {
+				return p, nil
+			}
+			return nil, z.Err()
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			tag := string(name)
+			if tag == "base" {
+				continue
+			}
+			attrs := make(map[string]string)
+			for hasAttr {
+				var key, val []byte
+				key, val, hasAttr = z.TagAttr()
+				attrs[string(key)] = string(val)
+			}
+			if id := attrs["id"]; id != "" {
+				p.ids[id] = true
+			}
+			if tag == "a" && attrs["name"] != "" {
+				p.ids[attrs["name"]] = true
+			}
+			for _, key := range []string{"href", "src", "poster"} {
+				if v, ok := attrs[key]; ok {
+					addLink(v)
+				}
+			}
+			for _, candidate := range strings.Split(attrs["srcset"], ",") {
+				if fields := strings.Fields(candidate); len(fields) > 0 {
+					addLink(fields[0])
+				}
+			}
+			if tag == "meta" && strings.EqualFold(attrs["http-equiv"], "refresh") {
+				if _, u, ok := strings.Cut(attrs["content"], "url="); ok {
+					addLink(u)
+				}
+			}
+		}
+	}
+}
+
+// check 返回 link 无效的原因.
+func (c *checker) check(pagePath, link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return "invalid URL"
+	}
+	if u.Scheme != "" || u.Host != "" {
+		if c.baseURL == "" || (link != c.baseURL && !strings.HasPrefix(link, c.baseURL+"/") && !strings.HasPrefix(link, c.baseURL+"#")) {
+			return "" // 外部链接
+		}
+		u = &url.URL{Path: u.Path, RawQuery: u.RawQuery, Fragment: u.Fragment}
+		if u.Path == "" {
+			u.Path = "/"
+		}
+	}
+
+	base := &url.URL{Path: c.basePath + "/" + pagePath}
+	target := base.ResolveReference(u)
+	if !strings.HasPrefix(target.Path, c.basePath+"/") {
+		return fmt.Sprintf("outside the site root %s/", c.basePath)
+	}
+	file := c.resolve(strings.TrimPrefix(target.Path, c.basePath+"/"))
+	if file == "" {
+		return "not found"
+	}
+	frag := target.Fragment
+	if frag == "" || strings.EqualFold(frag, "top") {
+		return ""
+	}
+	if p, ok := c.pages[file]; ok && !p.ids[frag] {
+		return fmt.Sprintf("no element with id %q in %s", frag, file)
+	}
+	return ""
+}
+
+// resolve 返回站内路径 rel 对应的文件.
+func (c *checker) resolve(rel string) string {
+	if rel == "" || strings.HasSuffix(rel, "/") {
+		rel += "index.html"
+	}
+	if c.files[rel] {
+		return rel
+	}
+	if index := path.Join(rel, "index.html"); c.files[index] {
+		return index
+	}
+	return ""
+}
@@ -0,0 +1,87 @@
+package linkcheck_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/zhhc99/bgen/internal/linkcheck"
+)
+
+func write(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+	write(t, filepath.Join(dir, "index.html"), `<html><head>
+<link rel="stylesheet" href="/~john/style.css">
+<link rel="canonical" href="https://example.com/~john/">
+<link rel="alternate" href="https://example.com/~john/gone/">
+</head><body>
+<a href="/~john/posts/hello/">ok</a>
+<a href="/~john/posts/hello/#intro">ok</a>
+<a href="/~john/posts/hello/#missing">bad anchor</a>
+<a href="/~john/posts/renamed/">renamed</a>
+<a href="/posts/hello/">without base path</a>
+<a href="https://other.example.com/">external</a>
+<a href="mailto:john@example.com">mail</a>
+<a href="#top">top</a>
+<a id="here" href="#here">self</a>
+<img src="posts/hello/figure.png" srcset="posts/hello/figure.png 1x, posts/hello/figure@2x.png 2x">
+</body></html>`)
+	write(t, filepath.Join(dir, "style.css"), "")
+	write(t, filepath.Join(dir, "posts/hello/index.html"), `<h2 id="intro">Intro</h2>
+<a href="../../">home</a>
+<a href="figure.png">figure</a>
+<a href="../hello">directory without slash</a>
+<a href="#intro">ok</a>
+<a href="%E4%B8%AD%E6%96%87.pdf">encoded</a>`)
+	write(t, filepath.Join(dir, "posts/hello/figure.png"), "")
+	write(t, filepath.Join(dir, "posts/hello/中文.pdf"), "")
+	write(t, filepath.Join(dir, "old/index.html"), `<meta http-equiv="refresh" content="0; url=/~john/posts/moved/">`)
+
+	got, err := linkcheck.Check(dir, "https://example.com/~john", "/~john")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []linkcheck.Broken{
+		{"index.html", "https://example.com/~john/gone/", "not found"},
+		{"index.html", "/~john/posts/hello/#missing", `no element with id "missing" in posts/hello/index.html`},
+		{"index.html", "/~john/posts/renamed/", "not found"},
+		{"index.html", "/posts/hello/", "outside the site root /~john/"},
+		{"index.html", "posts/hello/figure@2x.png", "not found"},
+		{"old/index.html", "/~john/posts/moved/", "not found"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got:\n%v\nwant:\n%v", got, want)
+	}
+}
+
+// 一千个互相链接的页面应该很快检查完.
+func BenchmarkCheck(b *testing.B) {
+	dir := b.TempDir()
+	for i := range 1000 {
+		path := filepath.Join(dir, fmt.Sprintf("posts/p%d/index.html", i))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			b.Fatal(err)
+		}
+		content := fmt.Sprintf(`<h2 id="s">S</h2><a href="/posts/p%d/#s">next</a><a href="../p%d/">prev</a>`, (i+1)%1000, (i+999)%1000)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			b.Fatal(err)
+		}
+	}
+	for b.Loop() {
+		if broken, err := linkcheck.Check(dir, "", ""); err != nil || len(broken) > 0 {
+			b.Fatalf("got %v, %v", broken, err)
+		}
+	}
+}
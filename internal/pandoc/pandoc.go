@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"fmt"
 	"os/exec"
+	"regexp"
+	"strconv"
 	"strings"
 )
 
@@ -12,6 +14,19 @@ type Result struct {
 	TOC  string
 }
 
+// Error 是 pandoc 执行失败的错误.
+type Error struct {
+	Err    error
+	Stderr string
+	Line   int
+}
+
+func (e *Error) Error() string { return fmt.Sprintf("pandoc: %v\n%s", e.Err, e.Stderr) }
+
+func (e *Error) Unwrap() error { return e.Err }
+
+var reLine = regexp.MustCompile(`line (\d+)`)
+
 func Convert(markdown []byte) (*Result, error) {
 	args := []string{
 		"-f", "markdown+tex_math_dollars+pipe_tables+fenced_code_blocks+implicit_figures",
@@ -31,7 +46,11 @@ func Convert(markdown []byte) (*Result, error) {
 	cmd.Stderr = &stderr
 
 	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("pandoc: %w\n%s", err, stderr.String())
+		e := &Error{Err: err, Stderr: stderr.String()}
+		if m := reLine.FindStringSubmatch(e.Stderr); m != nil {
+			e.Line, _ = strconv.Atoi(m[1])
+		}
+		return nil, e
 	}
 
 	toc, body := splitTOC(extractBody(stdout.String()))
@@ -53,6 +53,7 @@ func Run(projectRoot string) error {
 
 const blogYAML = `title: Alice's Blog
 base_url: https://example.com   # your deployed site URL
+language: en
 hero:
   header: Alice
   content: Welcome to my blog!
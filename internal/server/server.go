@@ -129,7 +129,7 @@ func startWatcher(projectRoot, outDir string, b *broker) error {
 
 	_ = w.Add(filepath.Join(projectRoot, "blog.yaml"))
 
-	for _, dir := range []string{"content", "layouts", "static"} {
+	for _, dir := range []string{"content", "layouts", "static", "data"} {
 		addDirRecursive(w, filepath.Join(projectRoot, dir))
 	}
 
@@ -0,0 +1,19 @@
+package site
+
+// ArchiveYear 是归档页中一年的文章.
+type ArchiveYear struct {
+	Year  int
+	Posts []Post
+}
+
+// archives 按年份分组 posts 分区的文章, 新的在前.
+func (s *Site) archives() []ArchiveYear {
+	var years []ArchiveYear
+	for _, p := range s.Posts {
+		if n := len(years); n == 0 || years[n-1].Year != p.Date.Year() {
+			years = append(years, ArchiveYear{Year: p.Date.Year()})
+		}
+		years[len(years)-1].Posts = append(years[len(years)-1].Posts, p)
+	}
+	return years
+}
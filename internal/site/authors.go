@@ -0,0 +1,95 @@
+package site
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/zhhc99/bgen/internal/content"
+)
+
+type Author struct {
+	ID     string // front matter 中的写法
+	Name   string // 显示名, blog.yaml 中没有资料时等于 ID
+	Slug   string
+	URL    string // 如 /authors/alice/
+	Bio    string
+	Avatar string // 站内路径已带前缀, 可以直接使用
+	Email  string
+	Links  map[string]string
+	Posts  []Post // 不含 unlisted 文章
+}
+
+// String 让模板中的 {{.}} 输出作者名.
+func (a *Author) String() string { return a.Name }
+
+// resolveAuthors 返回文章的作者 id 列表: authors 优先, 其次 author, 最后是默认作者.
+func (s *Site) resolveAuthors(fm content.FrontMatter) []string {
+	if len(fm.Authors) > 0 {
+		return fm.Authors
+	}
+	if fm.Author != "" {
+		return []string{fm.Author}
+	}
+	if s.Config.FrontMatterDefaults.Author != "" {
+		return []string{s.Config.FrontMatterDefaults.Author}
+	}
+	return nil
+}
+
+func (s *Site) newAuthors(ids []string) ([]*Author, error) {
+	var authors []*Author
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		slug := content.Slugify(id)
+		if slug == "" {
+			return nil, fmt.Errorf("author %q has no URL-safe characters", id)
+		}
+		a, ok := s.authorIndex[slug]
+		if !ok {
+			a = &Author{ID: id, Name: id, Slug: slug, URL: "/authors/" + slug + "/"}
+			if ac, ok := s.Config.Authors[id]; ok {
+				if ac.Name != "" {
+					a.Name = ac.Name
+				}
+				a.Bio, a.Avatar, a.Email, a.Links = ac.Bio, ac.Avatar, ac.Email, ac.Links
+				// static/ 下的文件不区分语言, 使用 RootPath 前缀
+				if strings.HasPrefix(a.Avatar, "/") && !strings.HasPrefix(a.Avatar, "//") {
+					a.Avatar = s.Config.RootPath + a.Avatar
+				}
+			}
+			s.authorIndex[slug] = a
+		} else if a.ID != id {
+			return nil, fmt.Errorf("authors %q and %q both map to slug %q", a.ID, id, slug)
+		}
+		if !slices.Contains(authors, a) {
+			authors = append(authors, a)
+		}
+	}
+	return authors, nil
+}
+
+// collectAuthors 在文章排序后填充每位作者的文章列表和 s.Authors.
+func (s *Site) collectAuthors() {
+	for _, p := range s.listedPosts() {
+		for _, a := range p.Authors {
+			a.Posts = append(a.Posts, p)
+		}
+	}
+	s.Authors = nil
+	for _, slug := range slices.Sorted(maps.Keys(s.authorIndex)) {
+		if a := s.authorIndex[slug]; len(a.Posts) > 0 {
+			s.Authors = append(s.Authors, a)
+		}
+	}
+}
+
+// authorNames 用于兼容旧的 Post.Author 字段.
+func authorNames(authors []*Author) string {
+	names := make([]string, len(authors))
+	for i, a := range authors {
+		names[i] = a.Name
+	}
+	return strings.Join(names, ", ")
+}
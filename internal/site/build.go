@@ -1,34 +1,172 @@
 package site
 
 import (
+	"errors"
 	"fmt"
 	"html/template"
 	"os"
 	"path/filepath"
-	"regexp"
 	"sort"
 	"strings"
 
+	"github.com/zhhc99/bgen/internal/config"
 	"github.com/zhhc99/bgen/internal/content"
-	"github.com/zhhc99/bgen/internal/pandoc"
+	"github.com/zhhc99/bgen/internal/data"
+	"github.com/zhhc99/bgen/internal/git"
 )
 
 const (
 	contentDir = "content"
-	postsDir   = "content/posts"
+	tagsDir    = "content/tags"
+	dataDir    = "data"
 )
 
 var coverExts = []string{"jpg", "jpeg", "png", "webp", "gif"}
 
-func (s *Site) Build(projectRoot, outDir string) error {
-	if err := os.MkdirAll(outDir, 0755); err != nil {
-		return fmt.Errorf("creating output dir: %w", err)
+// Result 是一次构建的结果.
+type Result struct {
+	Warnings []string
+	Pages    map[string]string // 生成的 HTML 文件 (相对于输出目录的 slash 路径) -> 来源, 如 content/posts/hello.md
+}
+
+// Build 构建站点的所有语言版本. 有错误, 或 strict: true 时有警告, 不写出任何文件.
+func Build(cfg *config.Config, projectRoot, outDir string) (*Result, error) {
+	sites := loadSites(cfg, projectRoot)
+	res := &Result{Warnings: warnings(sites)}
+	// 找不到的图片只是警告
+	for _, err := range missingImages(sites) {
+		res.Warnings = append(res.Warnings, err.Error())
+	}
+	if err := collectErrors(sites, projectRoot); err != nil {
+		return res, err
+	}
+	if cfg.Strict && len(res.Warnings) > 0 {
+		return res, fmt.Errorf("%d warning(s) treated as errors (strict: true)", len(res.Warnings))
 	}
-	if err := s.loadPosts(filepath.Join(projectRoot, postsDir)); err != nil {
-		return fmt.Errorf("loading posts: %w", err)
+	res.Pages = pageSources(sites, projectRoot)
+	return res, writeSites(sites, projectRoot, outDir)
+}
+
+// Check 读取所有内容并加载模板. outDir 非空时同时构建站点.
+func Check(cfg *config.Config, projectRoot, outDir string) (*Result, error) {
+	sites := loadSites(cfg, projectRoot)
+	res := &Result{Warnings: warnings(sites)}
+	errs := missingImages(sites)
+	if err := collectErrors(sites, projectRoot); err != nil {
+		errs = append(errs, err)
+	}
+	if err := newErrors(errs); err != nil || outDir == "" {
+		return res, err
+	}
+	res.Pages = pageSources(sites, projectRoot)
+	return res, writeSites(sites, projectRoot, outDir)
+}
+
+func writeSites(sites []*Site, projectRoot, outDir string) error {
+	var errs []error
+	for _, s := range sites {
+		if err := s.write(projectRoot, filepath.Join(outDir, filepath.FromSlash(s.Config.LangPath))); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	// 静态文件由所有语言共享, 最后复制
+	if err := copyStaticFiles(projectRoot, outDir); err != nil {
+		errs = append(errs, fmt.Errorf("copying static files: %w", err))
+	}
+	return newErrors(errs)
+}
+
+func collectErrors(sites []*Site, projectRoot string) error {
+	errs := checkOutputs(sites, projectRoot)
+	for _, s := range sites {
+		s.preloadTemplates(projectRoot)
+		for _, err := range s.errs {
+			var fe *FileError
+			if len(sites) > 1 && !errors.As(err, &fe) {
+				err = fmt.Errorf("[%s] %w", s.Config.Lang, err)
+			}
+			errs = append(errs, err)
+		}
+	}
+	return newErrors(errs)
+}
+
+func loadSites(cfg *config.Config, projectRoot string) []*Site {
+	var sites []*Site
+	var languages []Language
+	history := git.Load(projectRoot, contentDir)
+	siteData, dataErr := data.Load(filepath.Join(projectRoot, dataDir))
+	for _, lc := range cfg.Localized() {
+		s := New(lc)
+		s.git = history
+		s.Data = siteData
+		s.load(projectRoot)
+		sites = append(sites, s)
+		languages = append(languages, Language{Code: lc.Lang, Title: lc.Title, URL: lc.LangPath + "/"})
 	}
-	if err := s.loadPages(filepath.Join(projectRoot, contentDir)); err != nil {
-		return fmt.Errorf("loading pages: %w", err)
+	// data/ 属于整个项目, 错误只记录一次
+	if dataErr != nil {
+		sites[0].errs = append(sites[0].errs, fmt.Errorf("loading data: %w", dataErr))
+	}
+	linkTranslations(sites)
+	for _, s := range sites {
+		s.Languages = languages
+	}
+	return sites
+}
+
+// missingImages 汇总所有站点中找不到的本地图片.
+func missingImages(sites []*Site) []error {
+	var errs []error
+	for _, s := range sites {
+		errs = append(errs, s.missingImages...)
+	}
+	if err := newErrors(errs); err != nil {
+		return err.(Errors)
+	}
+	return nil
+}
+
+// warnings 汇总所有站点的警告并去掉重复.
+func warnings(sites []*Site) []string {
+	var all []string
+	seen := make(map[string]bool)
+	for _, s := range sites {
+		for _, w := range s.warnings {
+			if !seen[w] {
+				seen[w] = true
+				all = append(all, w)
+			}
+		}
+	}
+	return all
+}
+
+// load 读取站点的所有内容.
+func (s *Site) load(projectRoot string) {
+	s.root = projectRoot
+	if err := s.loadPosts(projectRoot); err != nil {
+		s.errs = append(s.errs, fmt.Errorf("loading posts: %w", err))
+	}
+	if err := s.loadPages(projectRoot); err != nil {
+		s.errs = append(s.errs, fmt.Errorf("loading pages: %w", err))
+	}
+	if err := s.loadTagPages(filepath.Join(projectRoot, tagsDir)); err != nil {
+		s.errs = append(s.errs, fmt.Errorf("loading tag pages: %w", err))
+	}
+	s.loadListPages(projectRoot)
+	// 避免连带的菜单错误
+	if len(s.errs) > 0 {
+		return
+	}
+	if err := s.buildMenu(); err != nil {
+		s.errs = append(s.errs, fmt.Errorf("building menu: %w", err))
+	}
+}
+
+func (s *Site) write(projectRoot, outDir string) error {
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return fmt.Errorf("creating output dir: %w", err)
 	}
 	if err := s.render(projectRoot, outDir); err != nil {
 		return fmt.Errorf("rendering: %w", err)
@@ -36,202 +174,331 @@ func (s *Site) Build(projectRoot, outDir string) error {
 	if err := buildFeed(outDir, s); err != nil {
 		return fmt.Errorf("building feed: %w", err)
 	}
+	if err := buildSitemap(outDir, s); err != nil {
+		return fmt.Errorf("building sitemap: %w", err)
+	}
 	return nil
 }
 
-func (s *Site) loadPosts(postsPath string) error {
-	entries, err := os.ReadDir(postsPath)
+// loadPosts 读取所有分区的文章, 然后计算 tag, 相邻文章, 作者和系列.
+func (s *Site) loadPosts(projectRoot string) error {
+	for _, sec := range s.Sections {
+		s.loadSection(sec, filepath.Join(projectRoot, contentDir, sec.Name))
+	}
+	s.Posts = s.Sections[0].Posts
+
+	if err := s.collectTags(); err != nil {
+		return err
+	}
+	s.neighbours = make(map[string]postLinks)
+	for _, sec := range s.Sections {
+		s.linkPosts(sec.Posts)
+	}
+	s.collectAuthors()
+	return s.collectSeries()
+}
+
+func (s *Site) loadSection(sec *Section, dir string) {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return
+	}
+	s.walkPosts(sec, dir)
+
+	sort.Slice(sec.Posts, func(i, j int) bool {
+		return sec.Posts[i].Date.After(sec.Posts[j].Date)
+	})
+}
+
+// walkPosts 递归读取 dir 下的文章. 含有 index 文件的目录是 bundle, 其他目录继续向下查找.
+// 目录结构不影响 slug.
+func (s *Site) walkPosts(sec *Section, dir string) {
+	entries, err := os.ReadDir(dir)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil
-		}
-		return fmt.Errorf("reading posts dir: %w", err)
+		s.fail(dir, err)
+		return
 	}
 
 	for _, entry := range entries {
-		fullPath := filepath.Join(postsPath, entry.Name())
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		fullPath := filepath.Join(dir, entry.Name())
 
 		var post *Post
 		if entry.IsDir() {
-			post, err = s.loadBundlePost(fullPath)
-		} else if filepath.Ext(entry.Name()) == ".md" {
-			post, err = s.loadFlatPost(fullPath)
+			if !s.isBundle(fullPath) {
+				s.walkPosts(sec, fullPath)
+				continue
+			}
+			post, err = s.loadBundlePost(sec, fullPath)
+		} else if filepath.Ext(entry.Name()) == ".md" && !isListIndex(entry.Name()) {
+			post, err = s.loadFlatPost(sec, fullPath)
 		} else {
 			continue
 		}
 
 		if err != nil {
-			return fmt.Errorf("%s: %w", entry.Name(), err)
+			s.fail(fullPath, err)
+			continue
 		}
-		if post != nil {
-			s.Posts = append(s.Posts, *post)
+		if post == nil {
+			continue
+		}
+		if post.Unlisted {
+			sec.unlisted = append(sec.unlisted, *post)
+		} else {
+			sec.Posts = append(sec.Posts, *post)
 		}
 	}
+}
 
-	sort.Slice(s.Posts, func(i, j int) bool {
-		return s.Posts[i].Date.After(s.Posts[j].Date)
-	})
-	for _, p := range s.Posts {
-		for _, tag := range p.Tags {
-			s.Tags[tag] = append(s.Tags[tag], p)
-		}
+// allPosts 返回所有分区中包括 unlisted 在内的所有文章.
+func (s *Site) allPosts() []Post {
+	var all []Post
+	for _, sec := range s.Sections {
+		all = append(all, sec.allPosts()...)
 	}
-	return nil
+	return all
 }
 
-func (s *Site) loadFlatPost(mdPath string) (*Post, error) {
-	data, err := os.ReadFile(mdPath)
-	if err != nil {
-		return nil, err
+// listedPosts 返回所有分区中不含 unlisted 的文章, 按时间倒序.
+func (s *Site) listedPosts() []Post {
+	var all []Post
+	for _, sec := range s.Sections {
+		all = append(all, sec.Posts...)
 	}
-	pf, err := content.Parse(data)
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].Date.After(all[j].Date)
+	})
+	return all
+}
+
+func (s *Site) loadFlatPost(sec *Section, mdPath string) (*Post, error) {
+	pf, err := s.parseFile(mdPath)
 	if err != nil {
 		return nil, err
 	}
-	if pf.Front.Ignore {
+	base := strings.TrimSuffix(filepath.Base(mdPath), ".md")
+	name, fileLang := s.splitLang(base)
+	if pf.Front.Ignore || s.contentLang(fileLang, pf.Front) != s.Config.Lang {
 		return nil, nil
 	}
 
-	base := strings.TrimSuffix(filepath.Base(mdPath), ".md")
+	coverSrc, err := s.findCover(filepath.Dir(mdPath), base, name)
+	if err != nil {
+		return nil, err
+	}
+	name = s.applyDatePrefix(pf, name, mdPath)
 	slug := pf.Front.Slug
 	if slug == "" {
-		slug = base
+		slug = name
 	}
-	return s.buildPost(pf, slug, findCover(filepath.Dir(mdPath), base))
-}
-
-func (s *Site) loadBundlePost(bundleDir string) (*Post, error) {
-	indexPath := filepath.Join(bundleDir, "index.md")
-	data, err := os.ReadFile(indexPath)
+	post, err := s.buildPost(sec, pf, mdPath, slug, coverSrc)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, nil
-		}
 		return nil, err
 	}
-	pf, err := content.Parse(data)
-	if err != nil {
+	post.BundleFiles = make(map[string]string)
+	if err := s.resolveRefs(pf, mdPath, filepath.Dir(mdPath), false, post.BundleFiles); err != nil {
 		return nil, err
 	}
-	if pf.Front.Ignore {
+	post.translationKey = translationKey(pf.Front, name)
+	return post, nil
+}
+
+func (s *Site) loadBundlePost(sec *Section, bundleDir string) (*Post, error) {
+	pf, mdPath, err := s.readBundleIndex(bundleDir)
+	if err != nil {
+		return nil, s.fileError(mdPath, err)
+	}
+	if pf == nil {
 		return nil, nil
 	}
 
+	name := s.applyDatePrefix(pf, filepath.Base(bundleDir), mdPath)
 	slug := pf.Front.Slug
 	if slug == "" {
-		slug = filepath.Base(bundleDir)
+		slug = name
 	}
 
 	// bundle 封面优先找 cover.*, 退回到 index.*
-	coverSrc := findCover(bundleDir, "cover")
-	if coverSrc == "" {
-		coverSrc = findCover(bundleDir, "index")
+	coverSrc, err := s.findCover(bundleDir, "cover", "index")
+	if err != nil {
+		return nil, s.fileError(mdPath, err)
 	}
 
-	post, err := s.buildPost(pf, slug, coverSrc)
+	post, err := s.buildPost(sec, pf, mdPath, slug, coverSrc)
 	if err != nil {
-		return nil, err
+		return nil, s.fileError(mdPath, err)
+	}
+	if post.BundleFiles, err = s.bundleFiles(bundleDir); err != nil {
+		return nil, s.fileError(mdPath, err)
+	}
+	if err := s.resolveRefs(pf, mdPath, bundleDir, true, post.BundleFiles); err != nil {
+		return nil, s.fileError(mdPath, err)
 	}
-	post.BundleImages = extractImageRefs(pf.Body, bundleDir)
+	post.translationKey = translationKey(pf.Front, name)
 	return post, nil
 }
 
-func (s *Site) buildPost(pf *content.ParsedFile, slug, coverSrc string) (*Post, error) {
-	result, err := pandoc.Convert(pf.Body)
-	if err != nil {
-		return nil, err
+// isBundle 判断 dir 是否含有任意语言的 index 文件.
+func (s *Site) isBundle(dir string) bool {
+	for _, name := range s.bundleIndexNames() {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return true
+		}
 	}
+	return false
+}
 
-	summary := pf.Front.Summary
-	if summary == "" {
-		summary = content.ExtractSummary(pf.Body)
+func (s *Site) bundleIndexNames() []string {
+	names := []string{"index.md"}
+	for _, l := range s.Config.Languages {
+		names = append(names, "index."+l.Code+".md")
 	}
+	return names
+}
 
-	author := pf.Front.Author
-	if author == "" {
-		author = s.Config.FrontMatterDefaults.Author
-	}
+// readBundleIndex 读取 bundle 中属于当前语言的 index.<lang>.md 或 index.md.
+// 没有 index 文件, 文件被 ignore 或属于其他语言时返回 nil.
+func (s *Site) readBundleIndex(bundleDir string) (*content.ParsedFile, string, error) {
+	return s.readLocalized(bundleDir, "index")
+}
 
-	var coverURL string
-	if coverSrc != "" {
-		coverURL = "/posts/" + slug + "/cover" + filepath.Ext(coverSrc)
-	}
-
-	return &Post{
-		Title:    pf.Front.Title,
-		Date:     pf.Front.Date,
-		Tags:     pf.Front.Tags,
-		Slug:     slug,
-		URL:      "/posts/" + slug + "/",
-		Summary:  summary,
-		Author:   author,
-		Cover:    coverURL,
-		CoverSrc: coverSrc,
-		Content:  template.HTML(result.Body),
-		TOC:      template.HTML(result.TOC),
-	}, nil
-}
-
-func (s *Site) loadPages(contentPath string) error {
-	entries, err := os.ReadDir(contentPath)
-	if err != nil {
-		return fmt.Errorf("reading content dir: %w", err)
+// readLocalized 读取 dir 中属于当前语言的 <base>.<lang>.md 或 <base>.md.
+func (s *Site) readLocalized(dir, base string) (*content.ParsedFile, string, error) {
+	names := []string{base + ".md"}
+	if len(s.Config.Languages) > 0 {
+		names = []string{base + "." + s.Config.Lang + ".md", base + ".md"}
 	}
-	for _, entry := range entries {
-		if entry.IsDir() || filepath.Ext(entry.Name()) != ".md" {
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		pf, err := s.parseFile(path)
+		if os.IsNotExist(err) {
 			continue
 		}
-		path := filepath.Join(contentPath, entry.Name())
-		data, err := os.ReadFile(path)
 		if err != nil {
-			return err
+			return nil, path, err
 		}
-		pf, err := content.Parse(data)
-		if err != nil {
-			return fmt.Errorf("%s: %w", path, err)
-		}
-		result, err := pandoc.Convert(pf.Body)
-		if err != nil {
-			return err
-		}
-		slug := strings.TrimSuffix(entry.Name(), ".md")
-		s.Pages[slug] = Page{
-			Title:   pf.Front.Title,
-			Slug:    slug,
-			URL:     "/" + slug + "/",
-			Content: template.HTML(result.Body),
+		_, fileLang := s.splitLang(strings.TrimSuffix(name, ".md"))
+		if pf.Front.Ignore || s.contentLang(fileLang, pf.Front) != s.Config.Lang {
+			return nil, "", nil
 		}
+		return pf, path, nil
 	}
-	return nil
+	return nil, "", nil
 }
 
-func findCover(dir, base string) string {
-	for _, ext := range coverExts {
-		p := filepath.Join(dir, base+"."+ext)
-		if _, err := os.Stat(p); err == nil {
-			return p
-		}
+// parseFile 读取并解析 markdown 文件.
+func (s *Site) parseFile(path string) (*content.ParsedFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pf, err := content.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range pf.Warnings {
+		s.warnf(path, "%s", w)
 	}
-	return ""
+	return pf, nil
 }
 
-// extractImageRefs 从 markdown 正文中提取本地图片引用.
-// 返回 map: 相对路径 -> 绝对路径.
-func extractImageRefs(body []byte, bundleDir string) map[string]string {
-	re := regexp.MustCompile(`!\[[^\]]*\]\(([^)\s]+)(?:\s+"[^"]*")?\)`)
-	images := make(map[string]string)
-	for _, m := range re.FindAllSubmatch(body, -1) {
-		if len(m) < 2 {
-			continue
-		}
-		imgPath := string(m[1])
-		if strings.HasPrefix(imgPath, "http://") || strings.HasPrefix(imgPath, "https://") {
-			continue
-		}
-		absPath := filepath.Join(bundleDir, imgPath)
-		if _, err := os.Stat(absPath); err == nil {
-			images[imgPath] = absPath
-		}
+// applyDatePrefix 处理 2024-01-15-hello 这样带日期前缀的文件名或 bundle 目录名:
+// front matter 没有 date 时使用前缀中的日期, 两者不一致时警告. 返回去掉前缀的名字.
+func (s *Site) applyDatePrefix(pf *content.ParsedFile, name, mdPath string) string {
+	prefix, date, rest := content.SplitDatePrefix(name)
+	if prefix == "" {
+		return name
+	}
+	if pf.Front.Date.IsZero() {
+		pf.Front.Date = date
+		return rest
+	}
+	layout := "2006-01-02"
+	if len(prefix) == len("2006-01") {
+		layout = "2006-01"
+	}
+	if got := pf.Front.Date.Resolve(s.Config.Location).Format(layout); got != prefix {
+		s.warnf(mdPath, "front matter date %s does not match file name date %s", got, prefix)
 	}
-	return images
+	return rest
+}
+
+// tocHTML 在 front matter 写了 toc: false 时返回空.
+func tocHTML(fm content.FrontMatter, toc string) template.HTML {
+	if fm.TOC != nil && !*fm.TOC {
+		return ""
+	}
+	return template.HTML(toc)
+}
+
+// translationKey 决定哪些不同语言的文件是同一篇内容的翻译.
+func translationKey(fm content.FrontMatter, name string) string {
+	if fm.TranslationKey != "" {
+		return fm.TranslationKey
+	}
+	return name
+}
+
+func (s *Site) buildPost(sec *Section, pf *content.ParsedFile, mdPath, slug, coverSrc string) (*Post, error) {
+	if err := checkSlug(slug); err != nil {
+		return nil, err
+	}
+	result, err := convert(pf)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := pf.Front.Summary
+	if summary == "" {
+		summary = content.ExtractSummary(pf.Body)
+	}
+
+	authors, err := s.newAuthors(s.resolveAuthors(pf.Front))
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := s.newTags(pf.Front.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	var coverURL string
+	if coverSrc != "" {
+		coverURL = sec.URL + slug + "/cover" + filepath.Ext(coverSrc)
+	}
+
+	post := &Post{
+		Title:       pf.Front.Title,
+		Date:        pf.Front.Date.Resolve(s.Config.Location),
+		Updated:     pf.Front.Updated.Resolve(s.Config.Location),
+		Tags:        tags,
+		Slug:        slug,
+		URL:         sec.URL + slug + "/",
+		Section:     sec.Name,
+		Summary:     summary,
+		Author:      authorNames(authors),
+		Authors:     authors,
+		Cover:       coverURL,
+		CoverSrc:    coverSrc,
+		Content:     template.HTML(result.Body),
+		TOC:         tocHTML(pf.Front, result.TOC),
+		Lang:        s.Config.Lang,
+		Unlisted:    pf.Front.Unlisted,
+		Series:      pf.Front.Series,
+		SeriesOrder: pf.Front.SeriesOrder,
+		Params:      pf.Front.Params,
+		source:      pf.Body,
+		sourcePath:  s.relPath(mdPath),
+	}
+	s.applyGitInfo(post, pf.Front, mdPath)
+	if post.Title == "" {
+		s.warnf(mdPath, "post has no title")
+	}
+	if post.Date.IsZero() {
+		s.warnf(mdPath, "post has no date, set date in front matter or use a dated file name")
+	}
+	return post, nil
 }
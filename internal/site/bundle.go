@@ -0,0 +1,176 @@
+package site
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/zhhc99/bgen/internal/content"
+)
+
+// bundleFiles 列出 bundle 中要复制的文件. 返回 map: 相对路径 -> 绝对路径.
+func (s *Site) bundleFiles(bundleDir string) (map[string]string, error) {
+	files := make(map[string]string)
+	err := filepath.WalkDir(bundleDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == bundleDir {
+			return nil
+		}
+		rel, _ := filepath.Rel(bundleDir, p)
+		rel = filepath.ToSlash(rel)
+		if strings.HasPrefix(d.Name(), ".") || s.bundleExcluded(rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if s.isBundle(p) {
+				return filepath.SkipDir // 嵌套的页面有自己的 bundle
+			}
+			return nil
+		}
+		if filepath.Ext(p) == ".md" {
+			return nil
+		}
+		rel, abs, err := s.resolveInDir(bundleDir, rel)
+		if err != nil {
+			return fmt.Errorf("bundle file %w", err)
+		}
+		files[rel] = abs
+		return nil
+	})
+	return files, err
+}
+
+// bundleExcluded 判断 rel 是否匹配 bundle_exclude.
+func (s *Site) bundleExcluded(rel string) bool {
+	for _, pattern := range s.Config.BundleExclude {
+		if ok, _ := path.Match(pattern, rel); ok {
+			return true
+		}
+		if !strings.Contains(pattern, "/") {
+			if ok, _ := path.Match(pattern, path.Base(rel)); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// localRef 是 markdown 正文中指向本地文件的链接.
+type localRef struct {
+	target string // 去掉查询参数和锚点, 解码后的路径
+	line   int    // 在正文中的行号, 从 1 开始
+	image  bool   // ![..](..) 或 HTML 的 src, 而不是普通链接
+}
+
+var (
+	reMarkdownRef = regexp.MustCompile(`(!?)\[[^\]]*\]\(<?([^)\s>]+)>?(?:\s+"[^"]*")?\)`)
+	reRefDef      = regexp.MustCompile(`^ {0,3}\[[^\]]+\]:\s*<?([^\s>]+)>?`)
+	reHTMLRef     = regexp.MustCompile(`(?i)<[a-z]+\s[^>]*?\b(src|href|poster)\s*=\s*["']([^"']+)["']`)
+)
+
+// localRefs 找出正文中代码块以外指向本地文件的链接.
+func localRefs(body []byte) []localRef {
+	var refs []localRef
+	add := func(target string, line int, image bool) {
+		if !isLocalRef(target) {
+			return
+		}
+		u, err := url.Parse(target)
+		if err != nil || u.Path == "" {
+			return
+		}
+		refs = append(refs, localRef{target: u.Path, line: line, image: image})
+	}
+
+	var fence string
+	sc := bufio.NewScanner(bytes.NewReader(body))
+	sc.Buffer(nil, len(body)+1)
+	for n := 1; sc.Scan(); n++ {
+		text := sc.Text()
+		trimmed := strings.TrimSpace(text)
+		switch {
+		case fence != "":
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		case strings.HasPrefix(trimmed, "```"), strings.HasPrefix(trimmed, "~~~"):
+			fence = trimmed[:3]
+			continue
+		}
+		for _, m := range reMarkdownRef.FindAllStringSubmatch(text, -1) {
+			add(m[2], n, m[1] == "!")
+		}
+		if m := reRefDef.FindStringSubmatch(text); m != nil {
+			add(m[1], n, false)
+		}
+		for _, m := range reHTMLRef.FindAllStringSubmatch(text, -1) {
+			add(m[2], n, !strings.EqualFold(m[1], "href"))
+		}
+	}
+	return refs
+}
+
+// resolveRefs 检查正文中指向本地文件的链接. 不是 bundle 时, 引用的图片加入 files.
+func (s *Site) resolveRefs(pf *content.ParsedFile, mdPath, dir string, bundle bool, files map[string]string) error {
+	place := "the bundle"
+	if !bundle {
+		place = "the directory of " + filepath.Base(mdPath)
+	}
+	for _, ref := range localRefs(pf.Body) {
+		line := pf.BodyLine + ref.line - 1
+		clean := path.Clean(ref.target)
+		if !filepath.IsLocal(filepath.FromSlash(clean)) {
+			if ref.image {
+				return fmt.Errorf("line %d: image %q is outside %s", line, ref.target, place)
+			}
+			continue
+		}
+		if _, ok := files[clean]; ok || strings.HasSuffix(ref.target, "/") {
+			continue
+		}
+		if !bundle {
+			if !ref.image {
+				continue
+			}
+			rel, abs, err := s.resolveInDir(dir, clean)
+			switch {
+			case errors.Is(err, fs.ErrNotExist):
+				s.missingImage(mdPath, line, ref.target)
+			case err != nil:
+				return fmt.Errorf("line %d: image %w", line, err)
+			default:
+				files[rel] = abs
+			}
+			continue
+		}
+		info, err := os.Stat(filepath.Join(dir, filepath.FromSlash(clean)))
+		switch {
+		case errors.Is(err, fs.ErrNotExist) && ref.image:
+			s.missingImage(mdPath, line, ref.target)
+		case errors.Is(err, fs.ErrNotExist):
+			s.warnf(mdPath, "line %d: links to %q, which does not exist in the bundle", line, ref.target)
+		case err == nil && !info.IsDir() && s.bundleExcluded(clean):
+			s.warnf(mdPath, "line %d: links to %q, which is excluded by bundle_exclude", line, ref.target)
+		}
+	}
+	return nil
+}
+
+// missingImage 记录找不到的本地图片.
+func (s *Site) missingImage(mdPath string, line int, target string) {
+	s.missingImages = append(s.missingImages, s.fileError(mdPath, fmt.Errorf("line %d: image %q does not exist", line, target)))
+}
@@ -0,0 +1,110 @@
+package site
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/zhhc99/bgen/internal/content"
+	"github.com/zhhc99/bgen/internal/pandoc"
+)
+
+// FileError 是某个文件中的错误.
+type FileError struct {
+	Path string
+	Err  error
+}
+
+func (e *FileError) Error() string { return e.Path + ": " + e.Err.Error() }
+
+func (e *FileError) Unwrap() error { return e.Err }
+
+// Errors 是一次构建中发现的所有错误.
+type Errors []error
+
+func (es Errors) Error() string {
+	msgs := make([]string, len(es))
+	for i, err := range es {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// newErrors 展开, 去重并排序 errs. errs 为空时返回 nil.
+func newErrors(errs []error) error {
+	var all Errors
+	seen := make(map[string]bool)
+	var add func(err error)
+	add = func(err error) {
+		var nested Errors
+		if errors.As(err, &nested) {
+			for _, e := range nested {
+				add(e)
+			}
+			return
+		}
+		if !seen[err.Error()] {
+			seen[err.Error()] = true
+			all = append(all, err)
+		}
+	}
+	for _, err := range errs {
+		add(err)
+	}
+	if len(all) == 0 {
+		return nil
+	}
+	sort.SliceStable(all, func(i, j int) bool { return errorPath(all[i]) < errorPath(all[j]) })
+	return all
+}
+
+func errorPath(err error) string {
+	var fe *FileError
+	if errors.As(err, &fe) {
+		return fe.Path
+	}
+	return ""
+}
+
+// fileError 把 err 标记为 path 中的错误.
+func (s *Site) fileError(path string, err error) error {
+	var fe *FileError
+	if errors.As(err, &fe) {
+		return err
+	}
+	return &FileError{Path: s.relPath(path), Err: err}
+}
+
+// fail 记录 path 中的错误.
+func (s *Site) fail(path string, err error) {
+	s.errs = append(s.errs, s.fileError(path, err))
+}
+
+// convert 用 pandoc 转换 pf 的正文, 错误中的行号换算为文件中的行号.
+func convert(pf *content.ParsedFile) (*pandoc.Result, error) {
+	result, err := pandoc.Convert(pf.Body)
+	var pe *pandoc.Error
+	if err == nil || !errors.As(err, &pe) || pe.Line == 0 {
+		return result, err
+	}
+	msg := fmt.Sprintf("line %d: pandoc: %s", pe.Line+pf.BodyLine-1, strings.TrimSpace(pe.Stderr))
+	if q := quote(pf.Body, pe.Line, pf.BodyLine); q != "" {
+		msg += "\n" + q
+	}
+	return nil, errors.New(msg)
+}
+
+// quote 引用 src 的第 line 行及其前后各一行.
+func quote(src []byte, line, firstLine int) string {
+	lines := strings.Split(string(src), "\n")
+	var b strings.Builder
+	for i := max(line-2, 0); i < min(line+1, len(lines)); i++ {
+		marker := " "
+		if i == line-1 {
+			marker = ">"
+		}
+		fmt.Fprintf(&b, "  %s %4d | %s\n", marker, i+firstLine, lines[i])
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
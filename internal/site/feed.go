@@ -2,6 +2,7 @@ package site
 
 import (
 	"encoding/xml"
+	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -22,15 +23,17 @@ type rssRoot struct {
 	Version   string     `xml:"version,attr"`
 	ContentNS string     `xml:"xmlns:content,attr"`
 	AtomNS    string     `xml:"xmlns:atom,attr"`
+	DCNS      string     `xml:"xmlns:dc,attr"`
 	Channel   rssChannel `xml:"channel"`
 }
 
 type rssChannel struct {
-	Title       string      `xml:"title"`
-	Link        string      `xml:"link"`
-	AtomLink    rssAtomLink `xml:"atom:link"`
-	Description string      `xml:"description"`
-	Items       []rssItem   `xml:"item"`
+	Title         string      `xml:"title"`
+	Link          string      `xml:"link"`
+	AtomLink      rssAtomLink `xml:"atom:link"`
+	Description   string      `xml:"description"`
+	LastBuildDate string      `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem   `xml:"item"`
 }
 
 type rssAtomLink struct {
@@ -43,7 +46,10 @@ type rssItem struct {
 	Title          string   `xml:"title"`
 	Link           string   `xml:"link"`
 	GUID           rssGUID  `xml:"guid"`
+	Author         string   `xml:"author,omitempty"` // RSS 2.0 要求是邮箱, 只在配置了 email 时输出
+	Creators       []string `xml:"dc:creator"`
 	PubDate        string   `xml:"pubDate"`
+	Updated        string   `xml:"atom:updated,omitempty"`
 	Description    rssCDATA `xml:"description"`
 	ContentEncoded rssCDATA `xml:"content:encoded"`
 }
@@ -61,35 +67,73 @@ func buildFeed(outDir string, s *Site) error {
 	if s.Config.BaseURL == "" {
 		return nil
 	}
+	if err := writeFeed(filepath.Join(outDir, "feed.xml"), s, s.Config.Title, "/", s.Posts); err != nil {
+		return err
+	}
+	for _, sec := range s.Sections[1:] {
+		dest := filepath.Join(outDir, filepath.FromSlash(sec.URL), "feed.xml")
+		if err := writeFeed(dest, s, s.Config.Title+" - "+sec.Title, sec.URL, sec.Posts); err != nil {
+			return fmt.Errorf("feed for section %s: %w", sec.Name, err)
+		}
+	}
+	for _, a := range s.Authors {
+		dest := filepath.Join(outDir, filepath.FromSlash(a.URL), "feed.xml")
+		if err := writeFeed(dest, s, s.Config.Title+" - "+a.Name, a.URL, a.Posts); err != nil {
+			return fmt.Errorf("feed for author %s: %w", a.ID, err)
+		}
+	}
+	return nil
+}
 
-	posts := s.Posts
+// writeFeed 写出 posts 的 RSS, link 是频道对应页面的站内路径, feed 位于 link + "feed.xml".
+func writeFeed(dest string, s *Site, title, link string, posts []Post) error {
 	if len(posts) > feedMaxItems {
 		posts = posts[:feedMaxItems]
 	}
 
+	var lastMod time.Time
 	items := make([]rssItem, 0, len(posts))
 	for _, p := range posts {
 		permalink := s.Config.BaseURL + p.URL
-		items = append(items, rssItem{
+		item := rssItem{
 			Title:          p.Title,
 			Link:           permalink,
 			GUID:           rssGUID{IsPermaLink: true, Value: permalink},
-			PubDate:        p.Date.UTC().Format(time.RFC1123Z),
+			PubDate:        p.Date.Format(time.RFC1123Z),
 			Description:    rssCDATA{p.Summary},
 			ContentEncoded: rssCDATA{buildContent(&p, s.Config.BaseURL, p.URL)},
-		})
+		}
+		if !p.Updated.IsZero() {
+			item.Updated = p.Updated.Format(time.RFC3339)
+		}
+		if p.LastMod().After(lastMod) {
+			lastMod = p.LastMod()
+		}
+		for _, a := range p.Authors {
+			item.Creators = append(item.Creators, a.Name)
+			if item.Author == "" && a.Email != "" {
+				item.Author = a.Email + " (" + a.Name + ")"
+			}
+		}
+		items = append(items, item)
 	}
 
+	var lastBuild string
+	if !lastMod.IsZero() {
+		lastBuild = lastMod.Format(time.RFC1123Z)
+	}
 	feed := rssRoot{
 		Version:   "2.0",
 		ContentNS: "http://purl.org/rss/1.0/modules/content/",
 		AtomNS:    "http://www.w3.org/2005/Atom",
+		DCNS:      "http://purl.org/dc/elements/1.1/",
 		Channel: rssChannel{
-			Title:       s.Config.Title,
-			Link:        s.Config.BaseURL,
-			AtomLink:    rssAtomLink{Href: s.Config.BaseURL + "/feed.xml", Rel: "self", Type: "application/rss+xml"},
-			Description: s.Config.Title,
-			Items:       items,
+			Title:         title,
+			Link:          s.Config.BaseURL + link,
+			AtomLink:      rssAtomLink{Href: s.Config.BaseURL + link + "feed.xml", Rel: "self", Type: "application/rss+xml"},
+			Description:   title,
+			LastBuildDate: lastBuild,
+			Items:         items,
 		},
 	}
 
@@ -98,8 +142,11 @@ func buildFeed(outDir string, s *Site) error {
 		return err
 	}
 
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
 	out := append([]byte(xml.Header), data...)
-	return os.WriteFile(filepath.Join(outDir, "feed.xml"), out, 0644)
+	return os.WriteFile(dest, out, 0644)
 }
 
 func buildContent(p *Post, baseURL, postURL string) string {
@@ -0,0 +1,53 @@
+package site
+
+import (
+	"strings"
+	"time"
+
+	"github.com/zhhc99/bgen/internal/content"
+)
+
+// Revision 是文章的一次提交.
+type Revision struct {
+	Date      time.Time
+	Hash      string
+	ShortHash string
+	Subject   string
+}
+
+// applyGitInfo 用 git 历史补全文章信息:
+// 没有 date 时取第一次提交的时间, 没有 updated 时取最后一次提交的时间,
+// front matter 中 history: true 时填充 History.
+func (s *Site) applyGitInfo(p *Post, fm content.FrontMatter, mdPath string) {
+	rel := s.relPath(mdPath)
+	if s.Config.SourceURL != "" {
+		repoPath := rel
+		if s.git != nil {
+			repoPath = s.git.Prefix + rel
+		}
+		p.SourceURL = strings.ReplaceAll(s.Config.SourceURL, "{path}", repoPath)
+	}
+	if s.git == nil {
+		return
+	}
+	commits := s.git.Files[rel]
+	if len(commits) == 0 {
+		return
+	}
+	if p.Date.IsZero() {
+		p.Date = commits[len(commits)-1].Date.In(s.Config.Location)
+	}
+	if p.Updated.IsZero() {
+		p.Updated = commits[0].Date.In(s.Config.Location)
+	}
+	if fm.History {
+		for _, c := range commits {
+			p.History = append(p.History, Revision{
+				Date:      c.Date.In(s.Config.Location),
+				Hash:      c.Hash,
+				ShortHash: c.ShortHash,
+				Subject:   c.Subject,
+			})
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package site
+
+import (
+	"strings"
+
+	"github.com/zhhc99/bgen/internal/content"
+)
+
+// Language 描述站点的一种语言, 用于语言切换链接.
+type Language struct {
+	Code  string
+	Title string
+	URL   string // 该语言首页的路径, 不含 BasePath, 如 / 或 /en/
+}
+
+// Translation 指向同一篇内容的另一种语言版本.
+type Translation struct {
+	Lang  string
+	Title string
+	URL   string // 不含 RootPath, 但包含语言前缀, 如 /en/posts/hello/
+}
+
+// splitLang 把 "hello.en" 拆成 ("hello", "en"). 只识别 blog.yaml 中配置的语言.
+func (s *Site) splitLang(base string) (name, lang string) {
+	i := strings.LastIndex(base, ".")
+	if i == -1 {
+		return base, ""
+	}
+	for _, l := range s.Config.Languages {
+		if l.Code == base[i+1:] {
+			return base[:i], l.Code
+		}
+	}
+	return base, ""
+}
+
+// contentLang 决定内容的语言: 文件名后缀优先, 其次是 front matter 的 lang, 最后是默认语言.
+// 没有配置 languages 时所有内容都属于唯一的语言.
+func (s *Site) contentLang(fileLang string, fm content.FrontMatter) string {
+	if len(s.Config.Languages) == 0 {
+		return s.Config.Lang
+	}
+	if fileLang != "" {
+		return fileLang
+	}
+	if fm.Lang != "" {
+		return fm.Lang
+	}
+	return s.Config.Languages[0].Code
+}
+
+// linkTranslations 按 translation key 把各语言站点中的同一篇文章/页面互相关联.
+func linkTranslations(sites []*Site) {
+	posts := make(map[string][]Translation)
+	pages := make(map[string][]Translation)
+	for _, s := range sites {
+		for _, p := range s.allPosts() {
+			key := p.Section + "/" + p.translationKey
+			posts[key] = append(posts[key], Translation{s.Config.Lang, p.Title, s.Config.LangPath + p.URL})
+		}
+		for _, pg := range s.Pages {
+			pages[pg.translationKey] = append(pages[pg.translationKey], Translation{s.Config.Lang, pg.Title, s.Config.LangPath + pg.URL})
+		}
+	}
+
+	others := func(all []Translation, lang string) []Translation {
+		var ts []Translation
+		for _, t := range all {
+			if t.Lang != lang {
+				ts = append(ts, t)
+			}
+		}
+		return ts
+	}
+	for _, s := range sites {
+		for _, sec := range s.Sections {
+			for _, list := range [][]Post{sec.Posts, sec.unlisted} {
+				for i := range list {
+					list[i].Translations = others(posts[sec.Name+"/"+list[i].translationKey], s.Config.Lang)
+				}
+			}
+		}
+		for _, pg := range s.Pages {
+			pg.Translations = others(pages[pg.translationKey], s.Config.Lang)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package site
+
+import (
+	"html/template"
+	"path/filepath"
+	"strings"
+)
+
+// listIndex 是为列表页提供内容的文件名 (不含语言后缀和扩展名).
+const listIndex = "_index"
+
+// ListPage 是 _index.md 为首页, tags 页或分区列表页提供的标题, 描述和正文.
+type ListPage struct {
+	Title       string
+	Description string
+	Content     template.HTML
+}
+
+// isListIndex 判断文件名是否为 _index.md 或 _index.<lang>.md.
+func isListIndex(name string) bool {
+	return strings.HasPrefix(name, listIndex+".") && filepath.Ext(name) == ".md"
+}
+
+// loadListPages 读取 content/_index.md, content/tags/_index.md 和各分区的 _index.md.
+func (s *Site) loadListPages(projectRoot string) {
+	s.home = s.readListPage(filepath.Join(projectRoot, contentDir))
+	s.tagsIndex = s.readListPage(filepath.Join(projectRoot, tagsDir))
+	for _, sec := range s.Sections {
+		sec.Index = s.readListPage(filepath.Join(projectRoot, contentDir, sec.Name))
+		if sec.Index != nil && sec.Index.Title != "" {
+			sec.Title = sec.Index.Title
+		}
+	}
+}
+
+// readListPage 读取 dir 下当前语言的 _index.md, 不存在时返回 nil.
+func (s *Site) readListPage(dir string) *ListPage {
+	pf, mdPath, err := s.readLocalized(dir, listIndex)
+	if err != nil {
+		s.fail(mdPath, err)
+		return nil
+	}
+	if pf == nil {
+		return nil
+	}
+	result, err := convert(pf)
+	if err != nil {
+		s.fail(mdPath, err)
+		return nil
+	}
+	return &ListPage{
+		Title:       pf.Front.Title,
+		Description: pf.Front.Description,
+		Content:     template.HTML(result.Body),
+	}
+}
@@ -0,0 +1,149 @@
+package site
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/zhhc99/bgen/internal/config"
+)
+
+// MenuItem 是导航菜单的一项.
+type MenuItem struct {
+	Label    string
+	URL      string // 站内链接不含 BasePath
+	External bool
+	Weight   int
+	Active   bool // 当前页面是该项或位于该项之下; 子项激活时父项也激活
+	Children []MenuItem
+	prefix   string // 激活时匹配的 URL 前缀, 默认为 URL
+}
+
+// builtinSections 是菜单可以引用的内置页面, 与 nav 的键相同.
+var builtinSections = []string{"search", "tags", "archives"}
+
+// Menu 返回导航菜单, Active 按正在渲染的页面计算.
+func (s *Site) Menu() []MenuItem {
+	items, _ := activeItems(s.menu, s.currentURL)
+	return items
+}
+
+func activeItems(items []MenuItem, url string) ([]MenuItem, bool) {
+	out := make([]MenuItem, len(items))
+	anyActive := false
+	for i, it := range items {
+		var childActive bool
+		it.Children, childActive = activeItems(it.Children, url)
+		prefix := cmp.Or(it.prefix, it.URL)
+		it.Active = childActive || !it.External && (url == it.URL || prefix != "/" && strings.HasPrefix(url, prefix))
+		anyActive = anyActive || it.Active
+		out[i] = it
+	}
+	return out, anyActive
+}
+
+// buildMenu 在页面加载后生成菜单. 没有配置 menu 时依次使用 nav 中启用的内置页面, 分区和顶层页面.
+// 菜单引用的内置页面即使没有在 nav 中设置也会生成.
+func (s *Site) buildMenu() error {
+	if len(s.Config.Menu) == 0 {
+		for _, name := range builtinSections {
+			if label := s.Config.Nav[name]; label != "" {
+				s.menu = append(s.menu, MenuItem{Label: label, URL: "/" + name + "/"})
+			}
+		}
+		for _, sec := range s.Sections {
+			if !sec.IsDefault() {
+				s.menu = append(s.menu, MenuItem{Label: sec.Title, URL: sec.URL})
+			}
+		}
+		for _, pg := range s.TopPages() {
+			if !pg.hidden {
+				s.menu = append(s.menu, MenuItem{Label: pg.Title, URL: pg.URL})
+			}
+		}
+		return nil
+	}
+	menu, err := s.menuItems(s.Config.Menu)
+	if err != nil {
+		return fmt.Errorf("blog.yaml: %w", err)
+	}
+	s.menu = menu
+	return nil
+}
+
+func (s *Site) menuItems(configs []config.MenuItemConfig) ([]MenuItem, error) {
+	items := make([]MenuItem, 0, len(configs))
+	for _, c := range configs {
+		item, ok, err := s.menuItem(c)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			items = append(items, item)
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Weight < items[j].Weight })
+	return items, nil
+}
+
+// menuItem 生成一项菜单. 非默认语言缺少翻译的页面被跳过, ok 为 false.
+func (s *Site) menuItem(c config.MenuItemConfig) (item MenuItem, ok bool, err error) {
+	item = MenuItem{Label: c.Label, Weight: c.Weight}
+	targets := 0
+	for _, v := range []string{c.Page, c.Section, c.URL} {
+		if v != "" {
+			targets++
+		}
+	}
+	if targets != 1 {
+		return item, false, fmt.Errorf("menu item %q: exactly one of page, section and url is required", c.Label)
+	}
+
+	switch {
+	case c.Page != "":
+		pg, found := s.Pages[strings.Trim(c.Page, "/")]
+		if !found && s.Config.LangPath != "" {
+			s.warnf(filepath.Join(s.root, "blog.yaml"), "menu: page %q has no %s version, skipped", c.Page, s.Config.Lang)
+			return item, false, nil
+		}
+		if !found {
+			return item, false, fmt.Errorf("menu item %q: page %q not found", c.Label, c.Page)
+		}
+		item.Label, item.URL = cmp.Or(item.Label, pg.Title), pg.URL
+	case slices.Contains(builtinSections, c.Section):
+		item.Label = cmp.Or(item.Label, s.Config.Nav[c.Section], s.Config.L10n[c.Section], c.Section)
+		item.URL = "/" + c.Section + "/"
+		if s.Config.Nav[c.Section] == "" {
+			// nav 决定是否生成内置页面, 以及页面标题
+			nav := maps.Clone(s.Config.Nav)
+			if nav == nil {
+				nav = make(map[string]string)
+			}
+			nav[c.Section] = item.Label
+			s.Config.Nav = nav
+		}
+	case c.Section != "":
+		i := slices.IndexFunc(s.Sections, func(sec *Section) bool { return sec.Name == c.Section })
+		if i == -1 {
+			return item, false, fmt.Errorf("menu item %q: unknown section %q", c.Label, c.Section)
+		}
+		sec := s.Sections[i]
+		item.Label, item.URL = cmp.Or(item.Label, sec.Title), sec.URL
+		if sec.IsDefault() {
+			item.URL, item.prefix = "/", sec.URL // posts 的列表页是首页
+		}
+	default:
+		if item.Label == "" {
+			return item, false, fmt.Errorf("menu item with url %q needs a label", c.URL)
+		}
+		item.URL = c.URL
+		item.External = !strings.HasPrefix(c.URL, "/") || strings.HasPrefix(c.URL, "//")
+	}
+
+	item.Children, err = s.menuItems(c.Children)
+	return item, err == nil, err
+}
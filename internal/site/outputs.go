@@ -0,0 +1,134 @@
+package site
+
+import (
+	"fmt"
+	"io/fs"
+	"maps"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// output 是构建会写出的一个文件.
+type output struct {
+	path   string
+	source string
+}
+
+// outputs 列出站点会写出的所有文件, 不含 static/.
+func (s *Site) outputs(projectRoot string) []output {
+	var outs []output
+	for _, job := range s.renderJobs(projectRoot) {
+		outs = append(outs, job.output)
+	}
+	if s.Config.Nav["search"] != "" {
+		outs = append(outs, output{"search.json", "the search index"})
+	}
+	if s.Config.BaseURL != "" {
+		outs = append(outs, output{"feed.xml", "the feed"}, output{"sitemap.xml", "the sitemap"})
+		for _, sec := range s.Sections[1:] {
+			outs = append(outs, output{strings.TrimPrefix(sec.URL, "/") + "feed.xml", fmt.Sprintf("the feed of section %q", sec.Name)})
+		}
+		for _, a := range s.Authors {
+			outs = append(outs, output{strings.TrimPrefix(a.URL, "/") + "feed.xml", fmt.Sprintf("the feed of author %q", a.ID)})
+		}
+	}
+
+	addFile := func(url, src string) {
+		outs = append(outs, output{strings.TrimPrefix(url, "/"), s.relPath(src)})
+	}
+	for _, p := range s.allPosts() {
+		if p.CoverSrc != "" {
+			addFile(p.Cover, p.CoverSrc)
+		}
+		for rel, abs := range sortedMap(p.BundleFiles) {
+			addFile(p.URL+filepath.ToSlash(rel), abs)
+		}
+	}
+	for _, tp := range s.Tags {
+		if tp.CoverSrc != "" {
+			addFile(tp.Cover, tp.CoverSrc)
+		}
+	}
+	for _, pg := range sortedMap(s.Pages) {
+		if pg.CoverSrc != "" {
+			addFile(pg.Cover, pg.CoverSrc)
+		}
+		for rel, abs := range sortedMap(pg.BundleFiles) {
+			addFile(pg.URL+filepath.ToSlash(rel), abs)
+		}
+	}
+	return outs
+}
+
+// pageSources 返回所有 HTML 页面及其来源.
+func pageSources(sites []*Site, projectRoot string) map[string]string {
+	pages := make(map[string]string)
+	for _, s := range sites {
+		prefix := strings.Trim(s.Config.LangPath, "/")
+		for _, job := range s.renderJobs(projectRoot) {
+			pages[path.Join(prefix, job.path)] = job.source
+		}
+	}
+	return pages
+}
+
+// checkOutputs 找出所有语言中写到同一路径的文件和 static/ 中离开目录的符号链接.
+func checkOutputs(sites []*Site, projectRoot string) []error {
+	var errs []error
+	written := make(map[string]string) // 输出路径 -> 来源
+	add := func(out output) {
+		other, ok := written[out.path]
+		if !ok {
+			written[out.path] = out.source
+			return
+		}
+		if other != out.source {
+			errs = append(errs, collisionError(out.path, other, out.source))
+		}
+	}
+	for _, s := range sites {
+		prefix := strings.Trim(s.Config.LangPath, "/")
+		for _, out := range s.outputs(projectRoot) {
+			out.path = path.Join(prefix, out.path)
+			add(out)
+		}
+	}
+	if len(sites) == 0 {
+		return errs
+	}
+	staticDir := filepath.Join(projectRoot, "static")
+	filepath.WalkDir(staticDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		rel, _ := filepath.Rel(staticDir, p)
+		rel = filepath.ToSlash(rel)
+		if _, _, err := sites[0].resolveInDir(staticDir, rel); err != nil {
+			errs = append(errs, fmt.Errorf("static file %w", err))
+		} else if other, ok := written[rel]; ok && !sites[0].Config.StaticOverrides {
+			errs = append(errs, fmt.Errorf("%w (set static_overrides: true in blog.yaml to let static/ win)",
+				collisionError(rel, other, "static/"+rel)))
+		}
+		return nil
+	})
+	return errs
+}
+
+// collisionError 报告 a 和 b 都写出 path.
+func collisionError(path, a, b string) error {
+	a, b = min(a, b), max(a, b)
+	return fmt.Errorf("output %s is written by both %s and %s", path, a, b)
+}
+
+// sortedMap 按键的顺序遍历 m.
+func sortedMap[V any](m map[string]V) func(yield func(string, V) bool) {
+	return func(yield func(string, V) bool) {
+		for _, k := range slices.Sorted(maps.Keys(m)) {
+			if !yield(k, m[k]) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package site
+
+import (
+	"fmt"
+	"html/template"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/zhhc99/bgen/internal/content"
+)
+
+// loadPages 递归读取 content/ 下的独立页面, 跳过各分区和 tags 目录.
+// 含有 index 文件的目录是页面 bundle, 其中的其他 markdown 是它的子页面.
+func (s *Site) loadPages(projectRoot string) error {
+	contentPath := filepath.Join(projectRoot, contentDir)
+	skip := []string{filepath.Base(tagsDir)}
+	for _, sec := range s.Sections {
+		skip = append(skip, sec.Name)
+	}
+	entries, err := os.ReadDir(contentPath)
+	if err != nil {
+		return fmt.Errorf("reading content dir: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && slices.Contains(skip, entry.Name()) {
+			continue
+		}
+		s.loadPageEntry(contentPath, "", entry)
+	}
+	s.linkPages()
+	return nil
+}
+
+// loadPageEntry 读取一个页面或目录.
+func (s *Site) loadPageEntry(dir, prefix string, entry os.DirEntry) {
+	name := entry.Name()
+	fullPath := filepath.Join(dir, name)
+	if strings.HasPrefix(name, ".") {
+		return
+	}
+	if entry.IsDir() {
+		if s.isBundle(fullPath) {
+			pf, mdPath, err := s.readBundleIndex(fullPath)
+			if err != nil {
+				s.fail(mdPath, err)
+			} else if pf != nil {
+				if coverSrc, err := s.findCover(fullPath, "cover", "index"); err != nil {
+					s.fail(mdPath, err)
+				} else if pg, err := s.buildPage(pf, mdPath, prefix+name, coverSrc); err != nil {
+					s.fail(mdPath, err)
+				} else if pg.BundleFiles, err = s.bundleFiles(fullPath); err != nil {
+					s.fail(mdPath, err)
+				} else if err := s.resolveRefs(pf, mdPath, fullPath, true, pg.BundleFiles); err != nil {
+					s.fail(mdPath, err)
+				}
+			}
+		}
+		entries, err := os.ReadDir(fullPath)
+		if err != nil {
+			s.fail(fullPath, err)
+			return
+		}
+		for _, e := range entries {
+			s.loadPageEntry(fullPath, prefix+name+"/", e)
+		}
+		return
+	}
+
+	if filepath.Ext(name) != ".md" {
+		return
+	}
+	base := strings.TrimSuffix(name, ".md")
+	slug, fileLang := s.splitLang(base)
+	if slug == "index" || slug == listIndex {
+		return // bundle 的 index 已经处理过, _index 属于列表页
+	}
+	pf, err := s.parseFile(fullPath)
+	if err != nil {
+		s.fail(fullPath, err)
+		return
+	}
+	if pf.Front.Ignore || s.contentLang(fileLang, pf.Front) != s.Config.Lang {
+		return
+	}
+	coverSrc, err := s.findCover(dir, base, slug)
+	if err != nil {
+		s.fail(fullPath, err)
+		return
+	}
+	pg, err := s.buildPage(pf, fullPath, prefix+slug, coverSrc)
+	if err != nil {
+		s.fail(fullPath, err)
+		return
+	}
+	pg.BundleFiles = make(map[string]string)
+	if err := s.resolveRefs(pf, fullPath, dir, false, pg.BundleFiles); err != nil {
+		s.fail(fullPath, err)
+	}
+}
+
+func (s *Site) buildPage(pf *content.ParsedFile, mdPath, slug, coverSrc string) (*Page, error) {
+	if l := pf.Front.Layout; l != "" && !filepath.IsLocal(filepath.FromSlash(l)) {
+		return nil, fmt.Errorf("layout %q must be a name under layouts/", l)
+	}
+	result, err := convert(pf)
+	if err != nil {
+		return nil, err
+	}
+	pg := &Page{
+		Title:          pf.Front.Title,
+		Slug:           slug,
+		URL:            "/" + slug + "/",
+		Lang:           s.Config.Lang,
+		Summary:        pf.Front.Summary,
+		Content:        template.HTML(result.Body),
+		TOC:            tocHTML(pf.Front, result.TOC),
+		Weight:         pf.Front.Weight,
+		Params:         pf.Front.Params,
+		sourcePath:     s.relPath(mdPath),
+		translationKey: translationKey(pf.Front, slug),
+		hidden:         pf.Front.Menu != nil && !*pf.Front.Menu,
+		layout:         pf.Front.Layout,
+	}
+	if pg.Summary == "" {
+		pg.Summary = content.ExtractSummary(pf.Body)
+	}
+	if coverSrc != "" {
+		pg.CoverSrc = coverSrc
+		pg.Cover = pg.URL + "cover" + filepath.Ext(coverSrc)
+	}
+	// about.md 和 about/index.md 都是 /about/, Pages 只能保存一个
+	if other, ok := s.Pages[slug]; ok {
+		s.errs = append(s.errs, collisionError(slug+"/index.html", other.sourcePath, pg.sourcePath))
+		return pg, nil
+	}
+	s.Pages[slug] = pg
+	return pg, nil
+}
+
+// linkPages 为每个页面找到最近的上级页面, 并填充 Children.
+func (s *Site) linkPages() {
+	pages := slices.Collect(maps.Values(s.Pages))
+	sortPages(pages)
+	for _, pg := range pages {
+		slug := pg.Slug
+		for dir := path.Dir(slug); dir != "."; dir = path.Dir(dir) {
+			if parent, ok := s.Pages[dir]; ok {
+				pg.Parent = parent
+				parent.Children = append(parent.Children, pg)
+				break
+			}
+		}
+	}
+}
+
+// TopPages 返回没有上级目录的页面, 按 weight, slug 排序.
+func (s *Site) TopPages() []*Page {
+	var pages []*Page
+	for _, pg := range s.Pages {
+		if !strings.Contains(pg.Slug, "/") {
+			pages = append(pages, pg)
+		}
+	}
+	sortPages(pages)
+	return pages
+}
+
+func sortPages(pages []*Page) {
+	sort.Slice(pages, func(i, j int) bool {
+		if pages[i].Weight != pages[j].Weight {
+			return pages[i].Weight < pages[j].Weight
+		}
+		return pages[i].Slug < pages[j].Slug
+	})
+}
@@ -0,0 +1,79 @@
+package site
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// checkSlug 确认 front matter 中的 slug 是单个路径段.
+func checkSlug(slug string) error {
+	if slug == "." || slug == ".." || strings.ContainsAny(slug, `/\`) {
+		return fmt.Errorf("slug %q must be a single path segment without /, \\ or ..", slug)
+	}
+	return nil
+}
+
+// isLocalRef 判断 markdown 中的链接是否指向本地文件.
+func isLocalRef(ref string) bool {
+	if strings.HasPrefix(ref, "/") || strings.HasPrefix(ref, "#") {
+		return false
+	}
+	u, err := url.Parse(ref)
+	return err != nil || u.Scheme == ""
+}
+
+// resolveInDir 把 rel 解析为 dir 中的文件, rel 离开 dir 时返回错误.
+func (s *Site) resolveInDir(dir, rel string) (string, string, error) {
+	clean := filepath.Clean(filepath.FromSlash(rel))
+	if !filepath.IsLocal(clean) {
+		return "", "", fmt.Errorf("%q is outside %s/", rel, s.relPath(dir))
+	}
+	abs := filepath.Join(dir, clean)
+	real, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		return "", "", err
+	}
+	realDir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", "", fmt.Errorf("%q: %w", rel, err)
+	}
+	if r, err := filepath.Rel(realDir, real); err != nil || !filepath.IsLocal(r) {
+		return "", "", fmt.Errorf("%q links to a file outside %s/", rel, s.relPath(dir))
+	}
+	return filepath.ToSlash(clean), abs, nil
+}
+
+// findCover 依次查找 dir 中的 <base>.jpg 等封面图片.
+func (s *Site) findCover(dir string, bases ...string) (string, error) {
+	for _, base := range bases {
+		for _, ext := range coverExts {
+			_, abs, err := s.resolveInDir(dir, base+"."+ext)
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return "", fmt.Errorf("cover %w", err)
+			}
+			return abs, nil
+		}
+	}
+	return "", nil
+}
+
+// outputFile 返回 rel 在 outDir 中的路径, 并创建上级目录.
+func outputFile(outDir, rel string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(strings.TrimPrefix(rel, "/")))
+	if !filepath.IsLocal(clean) {
+		return "", fmt.Errorf("output path %q is outside the output directory", rel)
+	}
+	dest := filepath.Join(outDir, clean)
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return "", err
+	}
+	return dest, nil
+}
@@ -0,0 +1,186 @@
+package site
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// 每篇文章只保留权重最高的若干词, 保证上千篇文章时仍然很快.
+const relatedMaxTerms = 64
+
+type relatedCandidate struct {
+	idx    int
+	shared int
+	sim    float64
+}
+
+// linkPosts 为同一分区中已排序 (时间倒序) 的文章计算 Prev, Next 和 Related.
+func (s *Site) linkPosts(posts []Post) {
+	n := len(posts)
+	for i := range posts {
+		var l postLinks
+		if i+1 < n {
+			l.Prev = &posts[i+1]
+		}
+		if i > 0 {
+			l.Next = &posts[i-1]
+		}
+		s.neighbours[posts[i].URL] = l
+	}
+
+	limit := s.Config.RelatedPosts
+	if limit <= 0 || n < 2 {
+		return
+	}
+
+	byTag := make(map[string][]int)
+	for i, p := range posts {
+		for _, tag := range p.Tags {
+			byTag[tag.Slug] = append(byTag[tag.Slug], i)
+		}
+	}
+	vecs := tfidf(posts)
+	type posting struct {
+		doc int
+		w   float64
+	}
+	index := make(map[string][]posting)
+	for i, v := range vecs {
+		for term, w := range v {
+			index[term] = append(index[term], posting{i, w})
+		}
+	}
+
+	shared := make([]int, n)
+	sims := make([]float64, n)
+	for i, p := range posts {
+		for j := range shared {
+			shared[j], sims[j] = 0, 0
+		}
+		for _, tag := range p.Tags {
+			for _, j := range byTag[tag.Slug] {
+				shared[j]++
+			}
+		}
+		for term, w := range vecs[i] {
+			for _, ps := range index[term] {
+				sims[ps.doc] += w * ps.w
+			}
+		}
+
+		var cands []relatedCandidate
+		for j := range n {
+			if j != i && (shared[j] > 0 || sims[j] > 0) {
+				cands = append(cands, relatedCandidate{j, shared[j], sims[j]})
+			}
+		}
+		sort.SliceStable(cands, func(a, b int) bool {
+			if cands[a].shared != cands[b].shared {
+				return cands[a].shared > cands[b].shared
+			}
+			return cands[a].sim > cands[b].sim
+		})
+		if len(cands) > limit {
+			cands = cands[:limit]
+		}
+		l := s.neighbours[p.URL]
+		for _, c := range cands {
+			l.Related = append(l.Related, posts[c.idx])
+		}
+		s.neighbours[p.URL] = l
+	}
+}
+
+// tfidf 返回每篇文章归一化后的 TF-IDF 向量.
+func tfidf(posts []Post) []map[string]float64 {
+	counts := make([]map[string]int, len(posts))
+	df := make(map[string]int)
+	for i, p := range posts {
+		counts[i] = make(map[string]int)
+		for _, term := range tokenize(p.Title + "\n" + string(p.source)) {
+			if counts[i][term] == 0 {
+				df[term]++
+			}
+			counts[i][term]++
+		}
+	}
+
+	n := float64(len(posts))
+	vecs := make([]map[string]float64, len(posts))
+	for i, c := range counts {
+		type tw struct {
+			term string
+			w    float64
+		}
+		var terms []tw
+		for term, k := range c {
+			// 只出现在一篇文章里的词对相似度没有贡献
+			if df[term] < 2 {
+				continue
+			}
+			terms = append(terms, tw{term, float64(k) * math.Log(n/float64(df[term]))})
+		}
+		sort.Slice(terms, func(a, b int) bool {
+			if terms[a].w != terms[b].w {
+				return terms[a].w > terms[b].w
+			}
+			return terms[a].term < terms[b].term
+		})
+		if len(terms) > relatedMaxTerms {
+			terms = terms[:relatedMaxTerms]
+		}
+		var norm float64
+		for _, t := range terms {
+			norm += t.w * t.w
+		}
+		vecs[i] = make(map[string]float64, len(terms))
+		if norm == 0 {
+			continue
+		}
+		norm = math.Sqrt(norm)
+		for _, t := range terms {
+			vecs[i][t.term] = t.w / norm
+		}
+	}
+	return vecs
+}
+
+// tokenize 把文本切成小写单词; 连续的 CJK 字符切成二元组.
+func tokenize(text string) []string {
+	var tokens []string
+	var word []rune
+	var cjk []rune
+	flushWord := func() {
+		if len(word) > 1 {
+			tokens = append(tokens, strings.ToLower(string(word)))
+		}
+		word = word[:0]
+	}
+	flushCJK := func() {
+		if len(cjk) == 1 {
+			tokens = append(tokens, string(cjk))
+		}
+		for i := 0; i+1 < len(cjk); i++ {
+			tokens = append(tokens, string(cjk[i:i+2]))
+		}
+		cjk = cjk[:0]
+	}
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushCJK()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+	return tokens
+}
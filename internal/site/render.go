@@ -1,13 +1,20 @@
 package site
 
 import (
+	"cmp"
 	"encoding/json"
 	"fmt"
 	"html/template"
 	"io"
 	"io/fs"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/zhhc99/bgen/internal/l10n"
 )
 
 type searchItem struct {
@@ -16,104 +23,154 @@ type searchItem struct {
 	Date  string `json:"date"`
 }
 
-func (s *Site) render(projectRoot, outPath string) error {
-	searchEnabled := s.Config.Nav["search"] != ""
-	tagsEnabled := s.Config.Nav["tags"] != ""
-
-	// 预加载模板
-	coreTemplates := []string{"index", "404", "single", "page"}
-	for _, name := range coreTemplates {
-		if _, err := s.getTemplate(projectRoot, name); err != nil {
-			return fmt.Errorf("preloading template %s: %w", name, err)
+// preloadTemplates 加载站点会用到的所有模板.
+func (s *Site) preloadTemplates(projectRoot string) {
+	names := []string{"index", "404", "single", "page"}
+	if s.Config.Nav["search"] != "" {
+		names = append(names, "search")
+	}
+	if s.Config.Nav["tags"] != "" {
+		names = append(names, "tags", "tag")
+	}
+	if s.Config.Nav["archives"] != "" {
+		names = append(names, "archives")
+	}
+	if len(s.Series) > 0 {
+		names = append(names, "series")
+	}
+	if len(s.Authors) > 0 {
+		names = append(names, "author")
+	}
+	for _, sec := range s.Sections {
+		if !sec.IsDefault() {
+			names = append(names, s.sectionTemplate(projectRoot, sec, "list"))
 		}
+		names = append(names, s.sectionTemplate(projectRoot, sec, "single"))
 	}
-	if searchEnabled {
-		if _, err := s.getTemplate(projectRoot, "search"); err != nil {
-			return fmt.Errorf("preloading template search: %w", err)
+	for _, pg := range s.Pages {
+		if pg.layout != "" {
+			names = append(names, pg.layout)
 		}
 	}
-	if tagsEnabled {
-		for _, name := range []string{"tags", "tag"} {
-			if _, err := s.getTemplate(projectRoot, name); err != nil {
-				return fmt.Errorf("preloading template %s: %w", name, err)
-			}
+	for _, name := range names {
+		if _, ok := s.templateCache[name]; ok {
+			continue
+		}
+		if _, err := s.getTemplate(projectRoot, name); err != nil {
+			s.errs = append(s.errs, fmt.Errorf("loading template %s: %w", name, err))
 		}
 	}
+}
 
-	if err := s.copyStaticFiles(projectRoot, outPath); err != nil {
-		return err
-	}
+// renderJob 是一个要渲染的页面.
+type renderJob struct {
+	output
+	name string // 模板名
+	data any
+}
+
+// render 渲染所有页面.
+func (s *Site) render(projectRoot, outPath string) error {
 	if err := s.copyCoverImages(outPath); err != nil {
 		return err
 	}
-	if err := s.copyBundleImages(outPath); err != nil {
+	if err := s.copyBundleFiles(outPath); err != nil {
 		return err
 	}
-	if searchEnabled {
+	if s.Config.Nav["search"] != "" {
 		if err := s.writeSearchJSON(outPath); err != nil {
 			return err
 		}
 	}
 
-	type renderJob struct {
-		path string
-		name string
-		data any
+	jobs := s.renderJobs(projectRoot)
+	var errs []error
+	for _, job := range jobs {
+		if err := s.renderPage(projectRoot, outPath, job.path, job.name, job.data); err != nil {
+			errs = append(errs, fmt.Errorf("rendering %s: %w", job.path, err))
+		}
 	}
+	return newErrors(errs)
+}
 
+// renderJobs 列出站点要渲染的所有页面.
+func (s *Site) renderJobs(projectRoot string) []renderJob {
+	job := func(path, source, name string, data any) renderJob {
+		return renderJob{output: output{path, source}, name: name, data: data}
+	}
 	base := struct{ Site *Site }{Site: s}
 
 	jobs := []renderJob{
-		{"index.html", "index", base},
-		{"404.html", "404", base},
-	}
-	if searchEnabled {
-		jobs = append(jobs, renderJob{"search/index.html", "search", base})
-	}
-	if tagsEnabled {
-		jobs = append(jobs, renderJob{"tags/index.html", "tags", base})
-		for tag, posts := range s.Tags {
-			tag, posts := tag, posts
-			jobs = append(jobs, renderJob{
-				"tags/" + tag + "/index.html",
-				"tag",
-				struct {
-					Site  *Site
-					Tag   string
-					Posts []Post
-				}{s, tag, posts},
-			})
+		job("index.html", "the home page", "index", struct {
+			Site *Site
+			Home *ListPage
+		}{s, s.home}),
+		job("404.html", "the 404 page", "404", base),
+	}
+	if s.Config.Nav["search"] != "" {
+		jobs = append(jobs, job("search/index.html", "the search page", "search", base))
+	}
+	if s.Config.Nav["tags"] != "" {
+		jobs = append(jobs, job("tags/index.html", "the tags page", "tags", struct {
+			Site  *Site
+			Index *ListPage
+		}{s, s.tagsIndex}))
+		for _, tp := range s.Tags {
+			jobs = append(jobs, job("tags/"+tp.Slug+"/index.html", fmt.Sprintf("the page of tag %q", tp.Name), "tag", struct {
+				Site  *Site
+				Tag   *TagPage
+				Posts []Post
+			}{s, tp, tp.Posts}))
 		}
 	}
-	for _, p := range s.Posts {
-		p := p
-		jobs = append(jobs, renderJob{
-			"posts/" + p.Slug + "/index.html",
-			"single",
-			struct {
-				Site *Site
-				Post Post
-			}{s, p},
-		})
+	if s.Config.Nav["archives"] != "" {
+		jobs = append(jobs, job("archives/index.html", "the archives page", "archives", struct {
+			Site  *Site
+			Years []ArchiveYear
+		}{s, s.archives()}))
 	}
-	for _, pg := range s.Pages {
-		pg := pg
-		jobs = append(jobs, renderJob{
-			pg.Slug + "/index.html",
-			"page",
-			struct {
-				Site *Site
-				Page Page
-			}{s, pg},
-		})
+	for _, sr := range s.Series {
+		jobs = append(jobs, job("series/"+sr.Slug+"/index.html", fmt.Sprintf("the page of series %q", sr.Name), "series", struct {
+			Site   *Site
+			Series *Series
+		}{s, sr}))
 	}
-
-	for _, job := range jobs {
-		if err := s.renderPage(projectRoot, outPath, job.path, job.name, job.data); err != nil {
-			return fmt.Errorf("rendering %s: %w", job.path, err)
+	for _, a := range s.Authors {
+		jobs = append(jobs, job("authors/"+a.Slug+"/index.html", fmt.Sprintf("the page of author %q", a.ID), "author", struct {
+			Site   *Site
+			Author *Author
+		}{s, a}))
+	}
+	for _, sec := range s.Sections {
+		if !sec.IsDefault() {
+			jobs = append(jobs, job(sec.Name+"/index.html", fmt.Sprintf("the list page of section %q", sec.Name), s.sectionTemplate(projectRoot, sec, "list"), struct {
+				Site    *Site
+				Section *Section
+				Index   *ListPage
+			}{s, sec, sec.Index}))
+		}
+		single := s.sectionTemplate(projectRoot, sec, "single")
+		for _, p := range sec.allPosts() {
+			links := s.neighbours[p.URL]
+			jobs = append(jobs, job(strings.TrimPrefix(p.URL, "/")+"index.html", p.sourcePath, single, struct {
+				Site    *Site
+				Post    Post
+				Prev    *Post
+				Next    *Post
+				Related []Post
+				Series  *Series
+				Section *Section
+			}{s, p, links.Prev, links.Next, links.Related, s.seriesOf(p), sec}))
 		}
 	}
-	return nil
+	for _, slug := range slices.Sorted(maps.Keys(s.Pages)) {
+		pg := s.Pages[slug]
+		jobs = append(jobs, job(pg.Slug+"/index.html", pg.sourcePath, cmp.Or(pg.layout, "page"), struct {
+			Site *Site
+			Page *Page
+		}{s, pg}))
+	}
+	return jobs
 }
 
 func (s *Site) renderPage(projectRoot, outPath, relPath, name string, data any) error {
@@ -121,10 +178,12 @@ func (s *Site) renderPage(projectRoot, outPath, relPath, name string, data any)
 	if err != nil {
 		return err
 	}
-	dest := filepath.Join(outPath, filepath.FromSlash(relPath))
-	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+	dest, err := outputFile(outPath, relPath)
+	if err != nil {
 		return err
 	}
+	// 页面逐个渲染, Site.Menu 据此计算 Active
+	s.currentURL = "/" + strings.TrimSuffix(relPath, "index.html")
 	f, err := os.Create(dest)
 	if err != nil {
 		return err
@@ -133,6 +192,15 @@ func (s *Site) renderPage(projectRoot, outPath, relPath, name string, data any)
 	return tmpl.ExecuteTemplate(f, "base.html", data)
 }
 
+// sectionTemplate 返回分区使用的模板名: 有 layouts/<section>/<kind>.html 时使用它, 否则使用 <kind>.
+func (s *Site) sectionTemplate(projectRoot string, sec *Section, kind string) string {
+	name := sec.Name + "/" + kind
+	if _, err := os.Stat(filepath.Join(projectRoot, "layouts", filepath.FromSlash(name)+".html")); err == nil {
+		return name
+	}
+	return kind
+}
+
 func (s *Site) getTemplate(projectRoot, name string) (*template.Template, error) {
 	if tmpl, ok := s.templateCache[name]; ok {
 		return tmpl, nil
@@ -164,7 +232,7 @@ func (s *Site) loadTemplate(projectRoot, name string) (*template.Template, error
 		return nil, fmt.Errorf("loading %s.html: %w", name, err)
 	}
 
-	tmpl := template.New("base.html")
+	tmpl := template.New("base.html").Funcs(s.funcs())
 	if _, err := tmpl.Parse(string(baseData)); err != nil {
 		return nil, err
 	}
@@ -174,7 +242,28 @@ func (s *Site) loadTemplate(projectRoot, name string) (*template.Template, error
 	return tmpl, nil
 }
 
-func (s *Site) copyStaticFiles(projectRoot, outPath string) error {
+// funcs 是所有模板可用的函数.
+func (s *Site) funcs() template.FuncMap {
+	return template.FuncMap{
+		// l10n 返回界面字符串, 未知的键原样返回以便发现
+		"l10n": func(key string) string {
+			if v, ok := s.Config.L10n[key]; ok {
+				return v
+			}
+			return key
+		},
+		// dateFormat 按 Go layout 格式化日期, 月份和星期名使用当前语言. layout 为空时使用 date_format
+		"dateFormat": func(layout string, t time.Time) string {
+			if layout == "" {
+				layout = s.Config.L10n["date_format"]
+			}
+			return l10n.FormatDate(t, layout, s.Config.Lang)
+		},
+	}
+}
+
+// copyStaticFiles 复制内置的静态文件和 static/.
+func copyStaticFiles(projectRoot, outPath string) error {
 	err := fs.WalkDir(embeddedFS, "static", func(path string, d fs.DirEntry, err error) error {
 		if err != nil || d.IsDir() {
 			return err
@@ -198,15 +287,28 @@ func (s *Site) copyStaticFiles(projectRoot, outPath string) error {
 }
 
 func (s *Site) copyCoverImages(outPath string) error {
-	for _, p := range s.Posts {
-		if p.CoverSrc == "" {
-			continue
+	covers := make(map[string]string) // 输出 URL -> 源文件
+	for _, p := range s.allPosts() {
+		if p.CoverSrc != "" {
+			covers[p.Cover] = p.CoverSrc
 		}
-		dest := filepath.Join(outPath, "posts", p.Slug, "cover"+filepath.Ext(p.CoverSrc))
-		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+	}
+	for _, tp := range s.Tags {
+		if tp.CoverSrc != "" {
+			covers[tp.Cover] = tp.CoverSrc
+		}
+	}
+	for _, pg := range s.Pages {
+		if pg.CoverSrc != "" {
+			covers[pg.Cover] = pg.CoverSrc
+		}
+	}
+	for url, src := range covers {
+		dest, err := outputFile(outPath, url)
+		if err != nil {
 			return err
 		}
-		f, err := os.Open(p.CoverSrc)
+		f, err := os.Open(src)
 		if err != nil {
 			return err
 		}
@@ -219,18 +321,22 @@ func (s *Site) copyCoverImages(outPath string) error {
 	return nil
 }
 
-func (s *Site) copyBundleImages(outPath string) error {
-	for _, p := range s.Posts {
-		if len(p.BundleImages) == 0 {
-			continue
+func (s *Site) copyBundleFiles(outPath string) error {
+	bundles := make(map[string]map[string]string) // 页面 URL -> bundle 中的文件
+	for _, p := range s.allPosts() {
+		if len(p.BundleFiles) > 0 {
+			bundles[p.URL] = p.BundleFiles
 		}
-		postDir := filepath.Join(outPath, "posts", p.Slug)
-		if err := os.MkdirAll(postDir, 0755); err != nil {
-			return err
+	}
+	for _, pg := range s.Pages {
+		if len(pg.BundleFiles) > 0 {
+			bundles[pg.URL] = pg.BundleFiles
 		}
-		for relPath, absPath := range p.BundleImages {
-			dest := filepath.Join(postDir, relPath)
-			if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+	}
+	for url, files := range bundles {
+		for relPath, absPath := range files {
+			dest, err := outputFile(outPath, url+relPath)
+			if err != nil {
 				return err
 			}
 			f, err := os.Open(absPath)
@@ -248,8 +354,9 @@ func (s *Site) copyBundleImages(outPath string) error {
 }
 
 func (s *Site) writeSearchJSON(outPath string) error {
-	items := make([]searchItem, len(s.Posts))
-	for i, p := range s.Posts {
+	posts := s.listedPosts()
+	items := make([]searchItem, len(posts))
+	for i, p := range posts {
 		items[i] = searchItem{
 			Title: p.Title,
 			URL:   p.URL,
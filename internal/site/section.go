@@ -0,0 +1,21 @@
+package site
+
+import "github.com/zhhc99/bgen/internal/config"
+
+// Section 是 content/ 下的一个内容分区, 如 posts, notes.
+// posts 以首页为列表页, feed 位于站点根目录; 其他分区有自己的列表页 /<name>/ 和 /<name>/feed.xml.
+type Section struct {
+	Name     string
+	Title    string
+	URL      string    // 如 /notes/
+	Index    *ListPage // content/<name>/_index.md, 没有时为 nil
+	Posts    []Post    // 不含 unlisted, 按时间倒序
+	unlisted []Post
+}
+
+// IsDefault 报告分区是否为 posts.
+func (sec *Section) IsDefault() bool { return sec.Name == config.DefaultSection }
+
+func (sec *Section) allPosts() []Post {
+	return append(append([]Post(nil), sec.Posts...), sec.unlisted...)
+}
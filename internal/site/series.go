@@ -0,0 +1,78 @@
+package site
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/zhhc99/bgen/internal/content"
+)
+
+type Series struct {
+	Name  string
+	Slug  string
+	URL   string
+	Posts []Post // 按 series_order 排序, 未指定时按日期
+}
+
+func (s *Site) collectSeries() error {
+	names := make(map[string]string) // slug -> 系列名
+	for _, p := range s.allPosts() {
+		if p.Series == "" {
+			continue
+		}
+		slug := content.Slugify(p.Series)
+		if slug == "" {
+			return fmt.Errorf("%s: series %q has no URL-safe characters", p.Slug, p.Series)
+		}
+		if name, ok := names[slug]; !ok {
+			names[slug] = p.Series
+		} else if !strings.EqualFold(name, p.Series) {
+			return fmt.Errorf("series %q and %q both map to slug %q", name, p.Series, slug)
+		}
+		if p.Unlisted {
+			continue
+		}
+		sr, ok := s.Series[slug]
+		if !ok {
+			sr = &Series{Name: names[slug], Slug: slug, URL: "/series/" + slug + "/"}
+			s.Series[slug] = sr
+		}
+		sr.Posts = append(sr.Posts, p)
+	}
+	for _, sr := range s.Series {
+		sortSeries(sr.Posts)
+	}
+	return nil
+}
+
+func sortSeries(posts []Post) {
+	sort.SliceStable(posts, func(i, j int) bool {
+		a, b := posts[i], posts[j]
+		// 指定了 series_order 的排在前面
+		if (a.SeriesOrder == 0) != (b.SeriesOrder == 0) {
+			return a.SeriesOrder != 0
+		}
+		if a.SeriesOrder != b.SeriesOrder {
+			return a.SeriesOrder < b.SeriesOrder
+		}
+		return a.Date.Before(b.Date)
+	})
+}
+
+// seriesOf 返回文章所属的系列, 不属于任何系列时为 nil.
+// unlisted 文章不在系列中, 它自己的页面上显示的系列包括它.
+func (s *Site) seriesOf(p Post) *Series {
+	if p.Series == "" {
+		return nil
+	}
+	sr := s.Series[content.Slugify(p.Series)]
+	if sr == nil || !p.Unlisted {
+		return sr
+	}
+	view := *sr
+	view.Posts = append(slices.Clone(sr.Posts), p)
+	sortSeries(view.Posts)
+	return &view
+}
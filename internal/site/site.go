@@ -2,47 +2,141 @@
 package site
 
 import (
+	"cmp"
+	"fmt"
 	"html/template"
+	"path/filepath"
 	"time"
 
 	"github.com/zhhc99/bgen/internal/config"
+	"github.com/zhhc99/bgen/internal/git"
+	"github.com/zhhc99/bgen/internal/l10n"
 )
 
 type Post struct {
 	Title        string
-	Date         time.Time
-	Tags         []string
+	Date         time.Time // 已转换到站点时区
+	Updated      time.Time // 未设置时为零值
+	Tags         []Tag
 	Slug         string
-	URL          string
+	URL          string // 如 /posts/hello/, /notes/hello/
+	Section      string // 所属分区, 如 posts
 	Summary      string
-	Author       string
+	Author       string // 所有作者的显示名, 逗号分隔
+	Authors      []*Author
 	Cover        string            // 生成后的 URL 路径, 空表示无封面
 	CoverSrc     string            // 构建期使用的源文件绝对路径
-	BundleImages map[string]string // markdown 中引用的图片: 相对路径 -> 绝对路径
+	BundleFiles  map[string]string // bundle 中除 markdown 外的文件, 或普通文章引用的图片: 相对路径 -> 绝对路径
 	Content      template.HTML
 	TOC          template.HTML
+	Lang         string
+	Translations []Translation // 其他语言的版本
+	Unlisted     bool          // 不出现在列表, feed, 搜索和相邻文章中, 但仍然生成页面
+	Series       string
+	SeriesOrder  int
+	History      []Revision     // front matter 中 history: true 时才有, 新的在前
+	SourceURL    string         // 由 blog.yaml 的 source_url 生成
+	Params       map[string]any // front matter 中的其他键
+
+	source         []byte
+	sourcePath     string // 相对于项目目录的 markdown 路径
+	translationKey string
 }
 
 type Page struct {
-	Title   string
-	Slug    string
-	URL     string
-	Content template.HTML
+	Title        string
+	Slug         string // 相对于 content/ 的路径, 如 about, docs/install
+	URL          string // 如 /docs/install/
+	Lang         string
+	Translations []Translation
+	Summary      string
+	Cover        string
+	CoverSrc     string
+	BundleFiles  map[string]string
+	Content      template.HTML
+	TOC          template.HTML
+	Weight       int
+	Params       map[string]any
+	Parent       *Page   // 最近的上级页面, 没有时为 nil
+	Children     []*Page // 按 weight, slug 排序
+
+	sourcePath     string
+	translationKey string
+	hidden         bool   // front matter 中 menu: false
+	layout         string // front matter 中的 layout, 为空时使用 page
+}
+
+// LastMod 返回文章的最后修改时间: 有 updated 时取 updated, 否则取发布日期.
+func (p Post) LastMod() time.Time {
+	if p.Updated.After(p.Date) {
+		return p.Updated
+	}
+	return p.Date
+}
+
+// postLinks 是文章页额外的导航数据, 每次构建计算一次.
+type postLinks struct {
+	Prev    *Post // 更早的一篇
+	Next    *Post // 更新的一篇
+	Related []Post
 }
 
 type Site struct {
 	Config        *config.Config
-	Posts         []Post
-	Tags          map[string][]Post
-	Pages         map[string]Page
+	Posts         []Post     // posts 分区中不含 unlisted 的文章
+	Sections      []*Section // 第一个是 posts
+	Tags          Tags
+	Pages         map[string]*Page   // 键为 slug
+	Series        map[string]*Series // 键为 slug
+	Authors       []*Author          // 按 slug 排序, 只包含有文章的作者
+	Languages     []Language         // 所有语言, 单语言站点只有一个
+	Data          map[string]any     // data/ 下的 yaml 和 json 文件, 所有语言共享
+	Params        map[string]any     // blog.yaml 的 params
+	home          *ListPage
+	tagsIndex     *ListPage
+	menu          []MenuItem
+	currentURL    string // 正在渲染的页面, 用于计算菜单的 Active
+	authorIndex   map[string]*Author
+	root          string
+	git           *git.History
+	warnings      []string
+	errs          []error // 加载过程中收集的错误, 见 errors.go
+	missingImages []error // 找不到的本地图片, build 中是警告, check 中是错误
+	neighbours    map[string]postLinks
 	templateCache map[string]*template.Template
 }
 
+// New 用 cfg.Lang 的界面字符串补全 cfg.L10n.
 func New(cfg *config.Config) *Site {
+	cfg.L10n = l10n.Strings(cfg.Lang, cfg.L10n)
+	sectionConfigs := cfg.Sections
+	if len(sectionConfigs) == 0 {
+		sectionConfigs = []config.SectionConfig{{Name: config.DefaultSection}}
+	}
+	sections := make([]*Section, len(sectionConfigs))
+	for i, sc := range sectionConfigs {
+		sections[i] = &Section{Name: sc.Name, Title: cmp.Or(sc.Title, sc.Name), URL: "/" + sc.Name + "/"}
+	}
 	return &Site{
 		Config:        cfg,
-		Tags:          make(map[string][]Post),
-		Pages:         make(map[string]Page),
+		Sections:      sections,
+		Params:        cfg.Params,
+		Pages:         make(map[string]*Page),
+		Series:        make(map[string]*Series),
+		authorIndex:   make(map[string]*Author),
 		templateCache: make(map[string]*template.Template),
 	}
 }
+
+// warnf 记录一条不影响构建的问题. path 是文件的绝对路径, 输出时转换为相对于项目的路径.
+func (s *Site) warnf(path, format string, args ...any) {
+	s.warnings = append(s.warnings, s.relPath(path)+": "+fmt.Sprintf(format, args...))
+}
+
+// relPath 返回相对于项目目录的 slash 路径.
+func (s *Site) relPath(path string) string {
+	if rel, err := filepath.Rel(s.root, path); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
@@ -0,0 +1,88 @@
+package site
+
+import (
+	"encoding/xml"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/zhhc99/bgen/internal/config"
+)
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	NS      string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// buildSitemap 写出 sitemap.xml. 文章的 lastmod 取 updated, 没有时取发布日期.
+func buildSitemap(outDir string, s *Site) error {
+	if s.Config.BaseURL == "" {
+		return nil
+	}
+
+	var siteMod time.Time
+	var urls []sitemapURL
+	add := func(url string, lastMod time.Time) {
+		u := sitemapURL{Loc: s.Config.BaseURL + url}
+		if !lastMod.IsZero() {
+			u.LastMod = lastMod.Format(time.RFC3339)
+		}
+		urls = append(urls, u)
+	}
+	for _, sec := range s.Sections[1:] {
+		var secMod time.Time
+		for _, p := range sec.Posts {
+			secMod = maxTime(secMod, p.LastMod())
+		}
+		add(sec.URL, secMod)
+	}
+	for _, p := range s.listedPosts() {
+		add(p.URL, p.LastMod())
+		if p.Section == config.DefaultSection {
+			siteMod = maxTime(siteMod, p.LastMod())
+		}
+	}
+	for _, slug := range slices.Sorted(maps.Keys(s.Pages)) {
+		add(s.Pages[slug].URL, time.Time{})
+	}
+	if s.Config.Nav["archives"] != "" {
+		add("/archives/", time.Time{})
+	}
+	if s.Config.Nav["tags"] != "" {
+		for _, tp := range s.Tags {
+			add(tp.URL, time.Time{})
+		}
+	}
+	for _, slug := range slices.Sorted(maps.Keys(s.Series)) {
+		add(s.Series[slug].URL, time.Time{})
+	}
+	for _, a := range s.Authors {
+		add(a.URL, time.Time{})
+	}
+	urls = append([]sitemapURL{{Loc: s.Config.BaseURL + "/"}}, urls...)
+	if !siteMod.IsZero() {
+		urls[0].LastMod = siteMod.Format(time.RFC3339)
+	}
+
+	data, err := xml.MarshalIndent(sitemapURLSet{NS: "http://www.sitemaps.org/schemas/sitemap/0.9", URLs: urls}, "", "  ")
+	if err != nil {
+		return err
+	}
+	out := append([]byte(xml.Header), data...)
+	return os.WriteFile(filepath.Join(outDir, "sitemap.xml"), out, 0644)
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,228 @@
+package site
+
+import (
+	"fmt"
+	"html/template"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/zhhc99/bgen/internal/content"
+)
+
+type Tag struct {
+	Name string // 显示名
+	Slug string // URL 中使用的名字
+	URL  string // 如 /tags/go/
+}
+
+// String 让旧模板里的 {{.}} 继续输出显示名.
+func (t Tag) String() string { return t.Name }
+
+// TagPage 是一个 tag 及其下的文章.
+// Title, Description, Cover 和 Content 来自可选的 content/tags/<tag>.md.
+type TagPage struct {
+	Tag
+	Title       string
+	Description string
+	Cover       string // 生成后的 URL 路径, 空表示无封面
+	CoverSrc    string
+	Content     template.HTML
+	Posts       []Post
+	Count       int
+	Weight      float64 // 按文章数归一化到 [0, 1], 用于 tag cloud
+}
+
+// Tags 默认按 slug 排序.
+type Tags []*TagPage
+
+// ByCount 返回按文章数倒序排列的副本, 文章数相同时按 slug 排序.
+func (ts Tags) ByCount() Tags {
+	sorted := slices.Clone(ts)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Count > sorted[j].Count
+	})
+	return sorted
+}
+
+func (ts Tags) find(slug string) *TagPage {
+	i, ok := slices.BinarySearchFunc(ts, slug, func(tp *TagPage, slug string) int {
+		return strings.Compare(tp.Slug, slug)
+	})
+	if !ok {
+		return nil
+	}
+	return ts[i]
+}
+
+// resolveTag 应用 tag_aliases, 返回规范化后的名字.
+func (s *Site) resolveTag(name string) string {
+	name = strings.TrimSpace(name)
+	for alias, target := range s.Config.TagAliases {
+		if strings.EqualFold(alias, name) {
+			return target
+		}
+	}
+	return name
+}
+
+func (s *Site) newTags(names []string) ([]Tag, error) {
+	var tags []Tag
+	seen := make(map[string]string) // slug -> 第一次出现的写法
+	for _, name := range names {
+		name = s.resolveTag(name)
+		slug := content.Slugify(name)
+		if slug == "" {
+			return nil, fmt.Errorf("tag %q has no URL-safe characters", name)
+		}
+		if prev, ok := seen[slug]; ok {
+			if !strings.EqualFold(prev, name) {
+				return nil, fmt.Errorf("tags %q and %q both map to slug %q", prev, name, slug)
+			}
+			continue
+		}
+		seen[slug] = name
+		tags = append(tags, Tag{Name: name, Slug: slug, URL: "/tags/" + slug + "/"})
+	}
+	return tags, nil
+}
+
+// collectTags 按 slug 合并 tag, 统一每篇文章上 tag 的显示名, 并填充 s.Tags.
+// 大小写不同的写法会合并, 显示名取出现最多的写法.
+// 不同的名字 (忽略大小写) 得到相同 slug 时报错.
+func (s *Site) collectTags() error {
+	spellings := make(map[string]map[string]int) // slug -> 写法 -> 次数
+	for _, sec := range s.Sections {
+		for _, p := range sec.allPosts() {
+			for _, t := range p.Tags {
+				if spellings[t.Slug] == nil {
+					spellings[t.Slug] = make(map[string]int)
+				}
+				spellings[t.Slug][t.Name]++
+			}
+		}
+	}
+
+	names := make(map[string]string, len(spellings))
+	for _, slug := range slices.Sorted(maps.Keys(spellings)) {
+		counts := spellings[slug]
+		var lowers []string
+		best := ""
+		for name, n := range counts {
+			if l := strings.ToLower(name); !slices.Contains(lowers, l) {
+				lowers = append(lowers, l)
+			}
+			if best == "" || n > counts[best] || n == counts[best] && name < best {
+				best = name
+			}
+		}
+		if len(lowers) > 1 {
+			sort.Strings(lowers)
+			return fmt.Errorf("tags %q and %q both map to slug %q", lowers[0], lowers[1], slug)
+		}
+		names[slug] = best
+	}
+
+	for _, sec := range s.Sections {
+		for _, posts := range [][]Post{sec.Posts, sec.unlisted} {
+			for i := range posts {
+				for j := range posts[i].Tags {
+					posts[i].Tags[j].Name = names[posts[i].Tags[j].Slug]
+				}
+			}
+		}
+	}
+	bySlug := make(map[string]*TagPage)
+	for _, p := range s.listedPosts() {
+		for _, t := range p.Tags {
+			tp, ok := bySlug[t.Slug]
+			if !ok {
+				tp = &TagPage{Tag: t}
+				bySlug[t.Slug] = tp
+			}
+			tp.Posts = append(tp.Posts, p)
+			tp.Count++
+		}
+	}
+	// 只被 unlisted 文章使用的 tag 没有 tag 页, 不生成链接
+	for _, sec := range s.Sections {
+		for _, p := range sec.unlisted {
+			for j, t := range p.Tags {
+				if bySlug[t.Slug] == nil {
+					p.Tags[j].URL = ""
+				}
+			}
+		}
+	}
+
+	s.Tags = nil
+	minCount, maxCount := 0, 0
+	for _, slug := range slices.Sorted(maps.Keys(bySlug)) {
+		tp := bySlug[slug]
+		s.Tags = append(s.Tags, tp)
+		if minCount == 0 || tp.Count < minCount {
+			minCount = tp.Count
+		}
+		maxCount = max(maxCount, tp.Count)
+	}
+	for _, tp := range s.Tags {
+		tp.Weight = 1
+		if maxCount > minCount {
+			tp.Weight = float64(tp.Count-minCount) / float64(maxCount-minCount)
+		}
+	}
+	return nil
+}
+
+// loadTagPages 读取 content/tags/<tag>.md, 为对应的 tag 页补充标题, 描述, 封面和正文.
+func (s *Site) loadTagPages(tagsPath string) error {
+	entries, err := os.ReadDir(tagsPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("reading tags dir: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".md" || isListIndex(entry.Name()) {
+			continue
+		}
+		base := strings.TrimSuffix(entry.Name(), ".md")
+		name, fileLang := s.splitLang(base)
+		path := filepath.Join(tagsPath, entry.Name())
+		pf, err := s.parseFile(path)
+		if err != nil {
+			s.fail(path, err)
+			continue
+		}
+		if pf.Front.Ignore || s.contentLang(fileLang, pf.Front) != s.Config.Lang {
+			continue
+		}
+		tp := s.Tags.find(content.Slugify(s.resolveTag(name)))
+		if tp == nil {
+			s.warnf(path, "no post uses tag %q", s.resolveTag(name))
+			continue
+		}
+		result, err := convert(pf)
+		if err != nil {
+			s.fail(path, err)
+			continue
+		}
+		tp.Title = pf.Front.Title
+		tp.Description = pf.Front.Description
+		tp.Content = template.HTML(result.Body)
+		src, err := s.findCover(tagsPath, base, name)
+		if err != nil {
+			s.fail(path, err)
+			continue
+		}
+		if src != "" {
+			tp.CoverSrc = src
+			tp.Cover = tp.URL + "cover" + filepath.Ext(src)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,260 @@
+// Package validate 按 Go 结构体的 yaml 标签检查 yaml 中的未知键和类型.
+package validate
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Issue 是检查发现的一个问题.
+type Issue struct {
+	Line    int
+	Message string
+	Error   bool // 类型错误和语法错误, 其余为警告
+}
+
+func (i Issue) String() string {
+	if i.Line == 0 {
+		return i.Message
+	}
+	return fmt.Sprintf("line %d: %s", i.Line, i.Message)
+}
+
+// YAML 按 T 的结构检查 data.
+func YAML[T any](data []byte) []Issue {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		msg := strings.TrimPrefix(err.Error(), "yaml: ")
+		if m := reSyntaxError.FindStringSubmatch(msg); m != nil {
+			line, _ := strconv.Atoi(m[1])
+			return []Issue{{Line: line, Message: m[2], Error: true}}
+		}
+		return []Issue{{Message: msg, Error: true}}
+	}
+	if len(doc.Content) == 0 {
+		return nil
+	}
+	var c checker
+	c.check(doc.Content[0], reflect.TypeFor[T](), "")
+	return c.issues
+}
+
+type checker struct {
+	issues []Issue
+}
+
+var unmarshalerType = reflect.TypeFor[yaml.Unmarshaler]()
+
+func (c *checker) check(node *yaml.Node, t reflect.Type, path string) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		return
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if reflect.PointerTo(t).Implements(unmarshalerType) {
+		if err := node.Decode(reflect.New(t).Interface()); err != nil {
+			c.errorf(node, path, "%s", reLinePrefix.ReplaceAllString(err.Error(), ""))
+		}
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Interface:
+	case reflect.Struct:
+		if c.expect(node, yaml.MappingNode, path) {
+			c.checkStruct(node, t, path)
+		}
+	case reflect.Map:
+		if c.expect(node, yaml.MappingNode, path) {
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				c.check(node.Content[i+1], t.Elem(), join(path, node.Content[i].Value))
+			}
+		}
+	case reflect.Slice:
+		if c.expect(node, yaml.SequenceNode, path) {
+			for i, item := range node.Content {
+				c.check(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+	default:
+		if !c.expect(node, yaml.ScalarNode, path) {
+			return
+		}
+		if err := node.Decode(reflect.New(t).Interface()); err != nil {
+			c.errorf(node, path, "expected %s, got %s", describeType(t), describeNode(node))
+		}
+	}
+}
+
+func (c *checker) checkStruct(node *yaml.Node, t reflect.Type, path string) {
+	fields, open := structFields(t)
+	known := make([]string, 0, len(fields))
+	for name := range fields {
+		known = append(known, name)
+	}
+	slices.Sort(known)
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if key.Value == "<<" {
+			continue
+		}
+		if ft, ok := fields[key.Value]; ok {
+			c.check(value, ft, join(path, key.Value))
+			continue
+		}
+		suggestion := suggest(key.Value, known)
+		switch {
+		case suggestion != "":
+			c.warnf(key, "unknown key %q, did you mean %q?", join(path, key.Value), suggestion)
+		case !open:
+			c.warnf(key, "unknown key %q", join(path, key.Value))
+		}
+	}
+}
+
+// structFields 返回 yaml 键到字段类型的映射.
+func structFields(t reflect.Type) (fields map[string]reflect.Type, open bool) {
+	fields = make(map[string]reflect.Type)
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag := f.Tag.Get("yaml")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if slices.Contains(strings.Split(opts, ","), "inline") {
+			if f.Type.Kind() == reflect.Map {
+				open = true
+			}
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = f.Type
+	}
+	return fields, open
+}
+
+var (
+	reLinePrefix  = regexp.MustCompile(`^line \d+: `)
+	reSyntaxError = regexp.MustCompile(`^line (\d+): (.*)$`)
+)
+
+func (c *checker) expect(node *yaml.Node, kind yaml.Kind, path string) bool {
+	if node.Kind == kind {
+		return true
+	}
+	c.errorf(node, path, "expected %s, got %s", describeKind(kind), describeNode(node))
+	return false
+}
+
+func (c *checker) errorf(node *yaml.Node, path, format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	if path != "" {
+		msg = fmt.Sprintf("%q: %s", path, msg)
+	}
+	c.issues = append(c.issues, Issue{Line: node.Line, Message: msg, Error: true})
+}
+
+func (c *checker) warnf(node *yaml.Node, format string, args ...any) {
+	c.issues = append(c.issues, Issue{Line: node.Line, Message: fmt.Sprintf(format, args...)})
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func describeKind(k yaml.Kind) string {
+	switch k {
+	case yaml.SequenceNode:
+		return "a list"
+	case yaml.MappingNode:
+		return "a mapping"
+	default:
+		return "a single value"
+	}
+}
+
+func describeNode(node *yaml.Node) string {
+	if node.Kind != yaml.ScalarNode {
+		return describeKind(node.Kind)
+	}
+	switch node.ShortTag() {
+	case "!!int", "!!float":
+		return "a number"
+	case "!!bool":
+		return "a boolean"
+	default:
+		return "a string"
+	}
+}
+
+func describeType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	default:
+		return "a string"
+	}
+}
+
+// suggest 返回与 key 最接近的已知键.
+func suggest(key string, known []string) string {
+	norm := func(s string) string { return strings.ReplaceAll(strings.ToLower(s), "-", "_") }
+	limit := max(1, len(key)/3)
+	best, bestDist := "", limit+1
+	for _, k := range known {
+		if d := distance(norm(key), norm(k)); d < bestDist {
+			best, bestDist = k, d
+		}
+	}
+	return best
+}
+
+// distance 计算编辑距离, 相邻字符交换算作一次编辑.
+func distance(a, b string) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
@@ -0,0 +1,71 @@
+package validate_test
+
+import (
+	"testing"
+
+	"github.com/zhhc99/bgen/internal/validate"
+)
+
+type item struct {
+	Name string `yaml:"name"`
+}
+
+type config struct {
+	BaseURL string            `yaml:"base_url"`
+	Count   int               `yaml:"count"`
+	Tags    []string          `yaml:"tags"`
+	Items   []item            `yaml:"items"`
+	Labels  map[string]string `yaml:"labels"`
+	Skipped string            `yaml:"-"`
+}
+
+type open struct {
+	Title string         `yaml:"title"`
+	Tags  []string       `yaml:"tags"`
+	Rest  map[string]any `yaml:",inline"`
+}
+
+func TestYAML(t *testing.T) {
+	issues := validate.YAML[config]([]byte(`base-url: x
+count: many
+tags: go
+items:
+  - nmae: a
+labels:
+  a: [b]
+Skipped: x
+`))
+	want := []validate.Issue{
+		{Line: 1, Message: `unknown key "base-url", did you mean "base_url"?`},
+		{Line: 2, Message: `"count": expected an integer, got a string`, Error: true},
+		{Line: 3, Message: `"tags": expected a list, got a string`, Error: true},
+		{Line: 5, Message: `unknown key "items[0].nmae", did you mean "name"?`},
+		{Line: 7, Message: `"labels.a": expected a single value, got a list`, Error: true},
+		{Line: 8, Message: `unknown key "Skipped"`},
+	}
+	if len(issues) != len(want) {
+		t.Fatalf("got %d issues, want %d: %v", len(issues), len(want), issues)
+	}
+	for i := range want {
+		if issues[i] != want[i] {
+			t.Errorf("issue %d: got %+v, want %+v", i, issues[i], want[i])
+		}
+	}
+}
+
+func TestYAML_Inline(t *testing.T) {
+	issues := validate.YAML[open]([]byte("title: x\ntag: go\naccent: teal\n"))
+	if len(issues) != 1 || issues[0].Line != 2 || issues[0].Message != `unknown key "tag", did you mean "tags"?` {
+		t.Errorf("got %v", issues)
+	}
+}
+
+func TestYAML_SyntaxError(t *testing.T) {
+	issues := validate.YAML[config]([]byte("tags: [\n"))
+	if len(issues) != 1 || !issues[0].Error {
+		t.Errorf("got %v", issues)
+	}
+	if issues := validate.YAML[config](nil); len(issues) != 0 {
+		t.Errorf("empty document: got %v", issues)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"os"
 	"path/filepath"
 	"runtime/debug"
+	"slices"
+	_ "time/tzdata" // 保证没有系统时区数据库的环境也能使用 timezone 配置
 
 	"github.com/zhhc99/bgen/internal/build"
 	"github.com/zhhc99/bgen/internal/scaffold"
@@ -36,6 +38,12 @@ func main() {
 	case "build":
 		outDir := parseOutputFlag(os.Args[2:], filepath.Join(".", "output"))
 		err = build.Run(".", outDir)
+	case "check":
+		if slices.Contains(os.Args[2:], "--links") {
+			err = build.CheckLinks(".")
+		} else {
+			err = build.Check(".")
+		}
 	case "serve":
 		err = server.Run(".")
 	case "version":
@@ -72,6 +80,8 @@ func printUsage() {
 	fmt.Fprintln(os.Stderr, "commands:")
 	fmt.Fprintln(os.Stderr, "  init                    initialize blog project scaffold")
 	fmt.Fprintln(os.Stderr, "  build [--output <dir>]  build site (default output: output/)")
+	fmt.Fprintln(os.Stderr, "  check [--links]         report problems in config and content,")
+	fmt.Fprintln(os.Stderr, "                          --links also checks internal links of the built site")
 	fmt.Fprintln(os.Stderr, "  serve                   start dev server with live reload")
 	fmt.Fprintln(os.Stderr, "  version                 print version")
 }